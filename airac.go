@@ -0,0 +1,80 @@
+package geo
+
+import(
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// AIRACCycle identifies one of the 28-day cycles on which aeronautical data changes, as YYNN;
+// e.g. 1604 is the fourth cycle to take effect in 2016. Cycles take effect at 00:00 UTC.
+type AIRACCycle int
+
+const kAIRACPeriodDays = 28
+var kAIRACEpoch = time.Date(2015, 1, 8, 0, 0, 0, 0, time.UTC) // Cycle 1501
+
+// airacIndex counts the cycles since the epoch; negative before it.
+func airacIndex(t time.Time) int {
+	days := int(t.Sub(kAIRACEpoch) / (24*time.Hour))
+	if t.Before(kAIRACEpoch.AddDate(0,0,days)) { days-- } // Round down, not towards zero
+	if days < 0 { return -((-days + kAIRACPeriodDays - 1) / kAIRACPeriodDays) }
+	return days / kAIRACPeriodDays
+}
+
+func airacIndexDate(i int) time.Time { return kAIRACEpoch.AddDate(0, 0, i*kAIRACPeriodDays) }
+
+// firstAIRACIndex is the index of the first cycle to take effect in the year.
+func firstAIRACIndex(year int) int {
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	i := airacIndex(jan1)
+	if airacIndexDate(i).Before(jan1) { i++ }
+	return i
+}
+
+// AIRACCycleAt returns the cycle in force at the time.
+func AIRACCycleAt(t time.Time) AIRACCycle {
+	i := airacIndex(t)
+	year := airacIndexDate(i).Year()
+	return AIRACCycle((year % 100) * 100 + i - firstAIRACIndex(year) + 1)
+}
+
+// ParseAIRACCycle parses "1604". It fails if the year doesn't have that many cycles. Years are
+// taken to be 20YY.
+func ParseAIRACCycle(s string) (AIRACCycle, error) {
+	n,err := strconv.Atoi(s)
+	if err != nil || len(s) != 4 { return 0, fmt.Errorf("AIRAC cycle '%s': not YYNN", s) }
+	c := AIRACCycle(n)
+	if n%100 < 1 || AIRACCycleAt(c.Effective()) != c {
+		return 0, fmt.Errorf("AIRAC cycle '%s': 20%02d has no cycle %02d", s, n/100, n%100)
+	}
+	return c, nil
+}
+
+func (c AIRACCycle)String() string { return fmt.Sprintf("%04d", int(c)) }
+
+func (c AIRACCycle)index() int {
+	return firstAIRACIndex(2000 + int(c)/100) + int(c)%100 - 1
+}
+
+// Effective is when the cycle takes effect.
+func (c AIRACCycle)Effective() time.Time { return airacIndexDate(c.index()) }
+
+// Expires is when the next cycle takes effect.
+func (c AIRACCycle)Expires() time.Time { return airacIndexDate(c.index() + 1) }
+
+func (c AIRACCycle)Next() AIRACCycle { return AIRACCycleAt(c.Expires()) }
+func (c AIRACCycle)Prev() AIRACCycle { return AIRACCycleAt(c.Effective().Add(-time.Second)) }
+
+// inForce says whether t is in [from,until); a zero time means no limit at that end.
+func inForce(from, until, t time.Time) bool {
+	if !from.IsZero() && t.Before(from) { return false }
+	if !until.IsZero() && !t.Before(until) { return false }
+	return true
+}
+
+// rangesOverlap says whether [from1,until1) and [from2,until2) overlap, as for inForce.
+func rangesOverlap(from1, until1, from2, until2 time.Time) bool {
+	if !until1.IsZero() && !from2.IsZero() && !from2.Before(until1) { return false }
+	if !until2.IsZero() && !from1.IsZero() && !from1.Before(until2) { return false }
+	return true
+}
@@ -0,0 +1,55 @@
+package geo
+
+import(
+	"testing"
+	"time"
+)
+
+func TestAIRACCycle(t *testing.T) {
+	date := func(y,m,d int) time.Time { return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct{
+		Cycle     AIRACCycle
+		Effective time.Time
+	}{
+		{1501, date(2015, 1, 8)},
+		{1513, date(2015,12,10)},
+		{1601, date(2016, 1, 7)},
+		{1604, date(2016, 3,31)},
+		{1412, date(2014,11,13)},
+		{1413, date(2014,12,11)},
+		{2001, date(2020, 1, 2)},
+		{2014, date(2020,12,31)}, // 2020 has fourteen cycles
+		{2101, date(2021, 1,28)},
+	}
+	for i,test := range tests {
+		if eff := test.Cycle.Effective(); !eff.Equal(test.Effective) {
+			t.Errorf("[%d] %s: expected effective %s, saw %s", i, test.Cycle, test.Effective, eff)
+		}
+		if c := AIRACCycleAt(test.Effective); c != test.Cycle {
+			t.Errorf("[%d] at %s: expected %s, saw %s", i, test.Effective, test.Cycle, c)
+		}
+		if c := AIRACCycleAt(test.Effective.Add(-time.Second)); c != test.Cycle.Prev() {
+			t.Errorf("[%d] just before %s: expected %s, saw %s", i, test.Effective, test.Cycle.Prev(), c)
+		}
+		if c := AIRACCycleAt(test.Cycle.Expires().Add(-time.Second)); c != test.Cycle {
+			t.Errorf("[%d] just before expiry: expected %s, saw %s", i, test.Cycle, c)
+		}
+	}
+
+	if c := AIRACCycle(1513).Next(); c != 1601 { t.Errorf("1513.Next: saw %s", c) }
+	if c := AIRACCycle(1601).Prev(); c != 1513 { t.Errorf("1601.Prev: saw %s", c) }
+
+	// A time in another zone, that is still the previous day in UTC
+	pst := time.FixedZone("PST", -8*3600)
+	if c := AIRACCycleAt(time.Date(2016, 3, 30, 20, 0, 0, 0, pst)); c != 1604 {
+		t.Errorf("2016/03/30 20:00 PST: expected 1604, saw %s", c)
+	}
+
+	for _,s := range []string{"1604", "2014"} {
+		if _,err := ParseAIRACCycle(s); err != nil { t.Errorf("ParseAIRACCycle(%s): %v", s, err) }
+	}
+	for _,s := range []string{"1514", "1500", "16O4", "160"} {
+		if c,err := ParseAIRACCycle(s); err == nil { t.Errorf("ParseAIRACCycle(%s): expected an error, saw %s", s, c) }
+	}
+}
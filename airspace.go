@@ -0,0 +1,250 @@
+package geo
+
+import(
+	"fmt"
+	"math"
+)
+
+// AltitudeReference says what an altitude limit is measured from.
+type AltitudeReference int
+const(
+	MSL AltitudeReference = iota // Feet above mean sea level
+	AGL                          // Feet above ground level
+	FlightLevel                  // Pressure altitude; FL180 is held as 18000 feet
+)
+
+// AltitudeLimit is a floor or a ceiling of an airspace volume.
+type AltitudeLimit struct {
+	Feet float64
+	Ref  AltitudeReference
+}
+
+var(
+	KSurface   = AltitudeLimit{0, AGL}
+	KUnlimited = AltitudeLimit{math.Inf(1), MSL}
+)
+
+func FeetMSL(f float64) AltitudeLimit { return AltitudeLimit{f, MSL} }
+func FeetAGL(f float64) AltitudeLimit { return AltitudeLimit{f, AGL} }
+
+func (al AltitudeLimit)IsSurface() bool   { return al.Ref == AGL && al.Feet == 0 }
+func (al AltitudeLimit)IsUnlimited() bool { return math.IsInf(al.Feet, 1) }
+
+func (al AltitudeLimit)String() string {
+	if al.IsSurface() { return "SFC" }
+	if al.IsUnlimited() { return "UNL" }
+	switch al.Ref {
+	case AGL:         return fmt.Sprintf("%.0fft AGL", al.Feet)
+	case FlightLevel: return fmt.Sprintf("FL%03.0f", al.Feet/100.0)
+	default:          return fmt.Sprintf("%.0fft MSL", al.Feet)
+	}
+}
+
+// FeetMSL converts the limit to feet above sea level, given the ground elevation (in feet).
+// Flight levels are passed through unchanged; they are only comparable to uncorrected altitudes.
+func (al AltitudeLimit)FeetMSL(groundElevationFeet float64) float64 {
+	if al.Ref == AGL { return al.Feet + groundElevationFeet }
+	return al.Feet
+}
+
+// AirspaceVolume is a laterally bounded shape, with a floor and a ceiling.
+type AirspaceVolume struct {
+	Name       string
+	Class      string // e.g. "B", "C", "D", "R"
+	Area       Area
+	Floor,Ceil AltitudeLimit
+}
+
+func (v AirspaceVolume)String() string {
+	return fmt.Sprintf("%s [%s,%s] %s", v.Name, v.Floor, v.Ceil, v.Area)
+}
+
+// FloorAt and CeilAt return feet MSL, given the ground elevation at the point in question.
+func (v AirspaceVolume)FloorAt(groundElevationFeet float64) float64 {
+	return v.Floor.FeetMSL(groundElevationFeet)
+}
+func (v AirspaceVolume)CeilAt(groundElevationFeet float64) float64 {
+	return v.Ceil.FeetMSL(groundElevationFeet)
+}
+
+// Contains is true if the point is laterally inside, and altFeet (MSL) lies in [floor,ceil].
+func (v AirspaceVolume)Contains(pos Latlong, altFeet, groundElevationFeet float64) bool {
+	if !v.Area.Contains(pos) { return false }
+	return altFeet >= v.FloorAt(groundElevationFeet) && altFeet <= v.CeilAt(groundElevationFeet)
+}
+
+// Airspace is a set of volumes; e.g. the shelves of a Class B, or the parts of a TFR.
+type Airspace struct {
+	Name      string
+	Volumes   []AirspaceVolume
+
+	// Elevation, if set, returns the ground elevation in feet at a point; it is needed to
+	// evaluate AGL limits. If nil, the ground is assumed to be at sea level.
+	Elevation func(Latlong) float64
+}
+
+func (a Airspace)String() string {
+	return fmt.Sprintf("Airspace %s (%d volumes)", a.Name, len(a.Volumes))
+}
+
+func (a Airspace)groundAt(pos Latlong) float64 {
+	if a.Elevation == nil { return 0.0 }
+	return a.Elevation(pos)
+}
+
+// VolumesAbove returns all the volumes that laterally contain the point, regardless of altitude.
+func (a Airspace)VolumesAbove(pos Latlong) []AirspaceVolume {
+	ret := []AirspaceVolume{}
+	for _,v := range a.Volumes {
+		if v.Area.Contains(pos) { ret = append(ret, v) }
+	}
+	return ret
+}
+
+// VolumeAt returns the first volume that contains the 3D point (altitude in feet MSL).
+func (a Airspace)VolumeAt(pos Latlong, altFeet float64) (AirspaceVolume, bool) {
+	ground := a.groundAt(pos)
+	for _,v := range a.Volumes {
+		if v.Contains(pos, altFeet, ground) { return v, true }
+	}
+	return AirspaceVolume{}, false
+}
+
+// FloorCeilAt returns the lowest floor and the highest ceiling (in feet MSL) of all the volumes
+// that lie above the point; inRange is false if there are none.
+func (a Airspace)FloorCeilAt(pos Latlong) (floor, ceil float64, inRange bool) {
+	ground := a.groundAt(pos)
+	floor,ceil = math.Inf(1), math.Inf(-1)
+	for _,v := range a.VolumesAbove(pos) {
+		floor = math.Min(floor, v.FloorAt(ground))
+		ceil  = math.Max(ceil, v.CeilAt(ground))
+		inRange = true
+	}
+	if !inRange { return 0, 0, false }
+	return
+}
+
+// AirspaceMargins describes how close a 3D point is to the edges of the airspace.
+type AirspaceMargins struct {
+	Volume            AirspaceVolume // The volume laterally containing the point (e.g. the shelf)
+	LateralNM         float64        // Distance to the nearest lateral edge of Volume
+
+	FloorMarginFeet   float64        // Altitude above Volume's floor; -ve if below it
+	CeilMarginFeet    float64        // Altitude below Volume's ceiling; -ve if above it
+
+	Neighbour         AirspaceVolume // The laterally closest other volume
+	HasNeighbour      bool
+	NeighbourNM       float64        // Distance to Neighbour's nearest lateral edge
+	NeighbourFloorMarginFeet float64 // Altitude above Neighbour's floor; -ve if below it
+}
+
+func (am AirspaceMargins)String() string {
+	str := fmt.Sprintf("%s: %.1fNM from edge, floor%+.0fft, ceil%+.0fft", am.Volume.Name,
+		am.LateralNM, am.FloorMarginFeet, am.CeilMarginFeet)
+	if am.HasNeighbour {
+		str += fmt.Sprintf("; %s %.1fNM away, floor%+.0fft", am.Neighbour.Name, am.NeighbourNM,
+			am.NeighbourFloorMarginFeet)
+	}
+	return str
+}
+
+// IsNearFloor is true if the point is above the floor, but by less than the given margin.
+func (am AirspaceMargins)IsNearFloor(feet float64) bool {
+	return am.FloorMarginFeet >= 0 && am.FloorMarginFeet < feet
+}
+
+// Margins finds the volume laterally containing the point (preferring one that also contains the
+// altitude, and then the one whose floor or ceiling is nearest), and reports the margins to its
+// edges, and to its nearest neighbour. If no volume lies above the point, the returned bool is
+// false; but the nearest volume is still given as the neighbour.
+func (a Airspace)Margins(pos Latlong, altFeet float64) (AirspaceMargins, bool) {
+	am := AirspaceMargins{}
+	ground := a.groundAt(pos)
+
+	// vertDist is zero if the altitude lies within the volume's altitude band
+	vertDist := func(v AirspaceVolume) float64 {
+		if floor := v.FloorAt(ground); altFeet < floor { return floor - altFeet }
+		if ceil := v.CeilAt(ground); altFeet > ceil { return altFeet - ceil }
+		return 0.0
+	}
+
+	iVol := -1
+	for i,v := range a.Volumes {
+		if !v.Area.Contains(pos) { continue }
+		if iVol < 0 || vertDist(v) < vertDist(a.Volumes[iVol]) { iVol = i }
+	}
+
+	if iVol >= 0 {
+		v := a.Volumes[iVol]
+		am.Volume = v
+		am.LateralNM = math.Abs(v.Area.SignedDistanceKM(pos)) * KNauticalMilePerKM
+		am.FloorMarginFeet = altFeet - v.FloorAt(ground)
+		am.CeilMarginFeet = v.CeilAt(ground) - altFeet
+	}
+
+	for i,v := range a.Volumes {
+		if i == iVol { continue }
+		distNM := math.Max(v.Area.SignedDistanceKM(pos), 0) * KNauticalMilePerKM
+		if !am.HasNeighbour || distNM < am.NeighbourNM {
+			am.Neighbour, am.NeighbourNM, am.HasNeighbour = v, distNM, true
+			am.NeighbourFloorMarginFeet = altFeet - v.FloorAt(a.groundAt(v.Area.NearestBoundaryPoint(pos)))
+		}
+	}
+
+	return am, (iVol >= 0)
+}
+
+// Margins reports how close the point is to the edges of the ClassB shelf it is in.
+func (m ClassBMap)Margins(pos Latlong, altFeet float64) (AirspaceMargins, bool) {
+	return m.ToAirspace().Margins(pos, altFeet)
+}
+
+// Implement MapRenderer interface
+func (a Airspace)ToLines() []LatlongLine {
+	ret := []LatlongLine{}
+	for _,v := range a.Volumes { ret = append(ret, v.Area.ToLines()...) }
+	return ret
+}
+func (a Airspace)ToCircles() []LatlongCircle {
+	ret := []LatlongCircle{}
+	for _,v := range a.Volumes { ret = append(ret, v.Area.ToCircles()...) }
+	return ret
+}
+
+// ToAirspace converts the map into a volume per cylinder step of each sector. The sector
+// bearings are magnetic, so they are converted to true bearings using the map's declination.
+func (m ClassBMap)ToAirspace() Airspace {
+	return Airspace{Name: m.Name, Volumes: m.volumes(func(Cylinder) bool { return true })}
+}
+
+// volumes returns the volumes for the cylinder steps that keep accepts.
+func (m ClassBMap)volumes(keep func(Cylinder) bool) []AirspaceVolume {
+	ret := []AirspaceVolume{}
+
+	for _,sector := range m.Sectors {
+		innerNM := 0
+		for _,cyl := range sector.Steps {
+			floor := FeetMSL(float64(cyl.Floor) * 100.0)
+			if cyl.Floor == 0 { floor = KSurface }
+
+			if keep(cyl) {
+				ret = append(ret, AirspaceVolume{
+					Name: fmt.Sprintf("%s %d/%d", m.Name, cyl.Ceil, cyl.Floor),
+					Class: "B",
+					Area: Sector{
+						Center: m.Center,
+						InnerRadiusKM: NM2KM(float64(innerNM)),
+						OuterRadiusKM: NM2KM(float64(cyl.EndDistanceNM)),
+						StartBearing: float64(sector.StartBearing) + m.Declination,
+						EndBearing: float64(sector.EndBearing) + m.Declination,
+					},
+					Floor: floor,
+					Ceil: FeetMSL(float64(cyl.Ceil) * 100.0),
+				})
+			}
+			innerNM = cyl.EndDistanceNM
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,133 @@
+package geo
+// go test -v github.com/skypies/geo
+
+import(
+	"math"
+	"testing"
+)
+
+func TestAirspaceFromClassB(t *testing.T) {
+	m := testWrappingClassBMap
+	m.Declination = 13.68
+	a := m.ToAirspace()
+
+	if len(a.Volumes) != 6 {
+		t.Fatalf("expected 6 volumes, saw %d", len(a.Volumes))
+	}
+
+	// The airspace should agree with the map wherever we look (avoiding the boundaries)
+	for bearing := 5.0; bearing < 360; bearing += 10 {
+		for distNM := 0.5; distNM < 20; distNM += 1.0 {
+			pos := m.Center.MoveKM(bearing, NM2KM(distNM))
+			mFloor,mCeil,mInRange,err := m.ClassBRange(pos)
+			if err != nil { t.Fatalf("ClassBRange: %v", err) }
+
+			aFloor,aCeil,aInRange := a.FloorCeilAt(pos)
+			if mInRange != aInRange || mFloor != aFloor || mCeil != aCeil {
+				t.Errorf("%.1fNM@%.0f: map said (%v,%.0f,%.0f), airspace said (%v,%.0f,%.0f)",
+					distNM, bearing, mInRange, mFloor, mCeil, aInRange, aFloor, aCeil)
+			}
+		}
+	}
+}
+
+func TestAirspaceVolumes(t *testing.T) {
+	center := Latlong{37,-122}
+	poly := center.Circle(5).ToPolygon(8)
+
+	a := Airspace{
+		Name: "TEST",
+		Volumes: []AirspaceVolume{
+			{Name: "core",  Area: center.Circle(2), Floor: KSurface,        Ceil: FeetMSL(4000)},
+			{Name: "shelf", Area: poly,             Floor: FeetAGL(1500),   Ceil: FeetMSL(4000)},
+			{Name: "wedge", Area: Sector{center, 5, 10, 0, 90}, Floor: FeetMSL(2500), Ceil: KUnlimited},
+		},
+		Elevation: func(pos Latlong) float64 { return 500 },
+	}
+
+	tests := []struct{
+		Pos      Latlong
+		Alt      float64
+		Expected string
+	}{
+		{center,                 1000, "core"},
+		{center,                 5000, ""},
+		{center.MoveKM(180, 3),  1000, ""},      // under the shelf (which is 2000 MSL here)
+		{center.MoveKM(180, 3),  2500, "shelf"},
+		{center.MoveKM( 45, 7),  2000, ""},
+		{center.MoveKM( 45, 7), 60000, "wedge"},
+		{center.MoveKM(225, 7), 60000, ""},
+	}
+	for i,test := range tests {
+		v,found := a.VolumeAt(test.Pos, test.Alt)
+		if found != (test.Expected != "") || v.Name != test.Expected {
+			t.Errorf("VolumeAt[%d]: expected %q, saw %q (found=%v)", i, test.Expected, v.Name, found)
+		}
+	}
+
+	if floor,ceil,inRange := a.FloorCeilAt(center.MoveKM(180, 3)); !inRange || floor != 2000 || ceil != 4000 {
+		t.Errorf("FloorCeilAt: expected (true,2000,4000), saw (%v,%.0f,%.0f)", inRange, floor, ceil)
+	}
+	if _,_,inRange := a.FloorCeilAt(center.MoveKM(225, 7)); inRange {
+		t.Errorf("FloorCeilAt: expected nothing out to the SW")
+	}
+
+	limitTests := map[AltitudeLimit]string{
+		KSurface:                 "SFC",
+		KUnlimited:               "UNL",
+		FeetMSL(4000):            "4000ft MSL",
+		FeetAGL(1500):            "1500ft AGL",
+		AltitudeLimit{18000, FlightLevel}: "FL180",
+	}
+	for limit,expected := range limitTests {
+		if limit.String() != expected {
+			t.Errorf("AltitudeLimit.String: expected %q, saw %q", expected, limit.String())
+		}
+	}
+}
+
+func TestAirspaceMargins(t *testing.T) {
+	m := testWrappingClassBMap // North: 0-5NM SFC, 5-10NM 20, 10-15NM 40
+	center := m.Center
+
+	tests := []struct{
+		DistNM, Alt         float64
+		Shelf, Neighbour    string
+		LateralNM           float64
+		FloorMargin         float64
+		NeighbourFloorMargin float64
+	}{
+		{ 9.5, 3000, "TEST 100/20", "TEST 100/40", 0.5, 1000, -1000},
+		{10.5, 3900, "TEST 100/40", "TEST 100/20", 0.5, -100,  1900},
+		{13.0, 4150, "TEST 100/40", "TEST 100/20", 2.0,  150,  2150}, // nearest edge is the outer limit
+	}
+
+	for i,test := range tests {
+		pos := center.MoveKM(0, NM2KM(test.DistNM))
+		am,inRange := m.Margins(pos, test.Alt)
+		if !inRange {
+			t.Errorf("[t%d] not in range", i)
+			continue
+		}
+		if am.Volume.Name != test.Shelf || am.Neighbour.Name != test.Neighbour {
+			t.Errorf("[t%d] expected %s next to %s, saw %s", i, test.Shelf, test.Neighbour, am)
+		}
+		if math.Abs(am.LateralNM - test.LateralNM) > 0.01 {
+			t.Errorf("[t%d] expected lateral margin %.2fNM, saw %s", i, test.LateralNM, am)
+		}
+		if am.FloorMarginFeet != test.FloorMargin || am.NeighbourFloorMarginFeet != test.NeighbourFloorMargin {
+			t.Errorf("[t%d] expected floor margins %.0f,%.0f, saw %s", i, test.FloorMargin,
+				test.NeighbourFloorMargin, am)
+		}
+	}
+
+	if am,_ := m.Margins(center.MoveKM(0, NM2KM(12)), 4150); !am.IsNearFloor(200) {
+		t.Errorf("expected to be within 200ft of the floor: %s", am)
+	}
+
+	// Outside all the shelves, we still get told how far away the nearest one is
+	am,inRange := m.Margins(center.MoveKM(0, NM2KM(16)), 3000)
+	if inRange || !am.HasNeighbour || math.Abs(am.NeighbourNM - 1.0) > 0.01 {
+		t.Errorf("expected to be 1NM outside, saw (%v) %s", inRange, am)
+	}
+}
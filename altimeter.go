@@ -0,0 +1,28 @@
+package geo
+
+import(
+	"fmt"
+	"time"
+)
+
+// AltimeterSource supplies the altimeter setting, in inches of mercury, in force at a given
+// place and time. This is what turns a pressure altitude (as broadcast by ADS-B) into an
+// indicated altitude (as flown by the pilot.)
+type AltimeterSource interface {
+	InchesHg(pos Latlong, t time.Time) (float64, error)
+	String() string // Describes where the setting comes from
+}
+
+// FixedAltimeter is a single setting, used for all places and times.
+type FixedAltimeter float64
+func (f FixedAltimeter)InchesHg(pos Latlong, t time.Time) (float64, error) { return float64(f), nil }
+func (f FixedAltimeter)String() string { return fmt.Sprintf("fixed %.2finHg", float64(f)) }
+
+// AltimeterFunc adapts a function (e.g. a lookup into archived weather reports) into an
+// AltimeterSource.
+type AltimeterFunc struct {
+	Name string
+	F    func(pos Latlong, t time.Time) (float64, error)
+}
+func (af AltimeterFunc)InchesHg(pos Latlong, t time.Time) (float64, error) { return af.F(pos, t) }
+func (af AltimeterFunc)String() string { return af.Name }
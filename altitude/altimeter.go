@@ -0,0 +1,74 @@
+package altitude
+// Altimeter settings. An altimeter reports the height in the standard atmosphere at which the
+// static pressure matches the outside air, offset by the setting in its Kollsman window:
+//  * with the standard setting (29.92inHg, 1013.25hPa), it shows pressure altitude
+//  * with QNH, it shows (roughly) height above sea level; this is the 'altimeter setting'
+//  * with QFE, it shows height above the airfield (QFE is the pressure at field elevation)
+// Above the transition altitude, everyone uses the standard setting, and altitudes are
+// flight levels (hundreds of feet of pressure altitude).
+
+import "math"
+
+const (
+	StandardSettingInHg = p0
+	StandardSettingHPa  = 1013.25
+
+	HPaPerInHg = PascalsPerInHg / 100.0 // Also millibars
+
+	KUSTransitionAltitude = 18000.0 // Feet; the transition level is FL180
+)
+
+func InchesHgToHPa(inHg float64) float64 { return inHg * HPaPerInHg }
+func HPaToInchesHg(hPa float64) float64 { return hPa / HPaPerInHg }
+
+// IndicatedAltitudeToPressureAltitude is the inverse of PressureAltitudeToIndicatedAltitude.
+func IndicatedAltitudeToPressureAltitude(indicatedAlt, inHg float64) float64 {
+	return indicatedAlt + StandardHeightFromBarometricPressure(inHg)
+}
+
+func PressureAltitudeToIndicatedAltitudeHPa(pressureAlt, hPa float64) float64 {
+	return PressureAltitudeToIndicatedAltitude(pressureAlt, HPaToInchesHg(hPa))
+}
+func IndicatedAltitudeToPressureAltitudeHPa(indicatedAlt, hPa float64) float64 {
+	return IndicatedAltitudeToPressureAltitude(indicatedAlt, HPaToInchesHg(hPa))
+}
+
+// QNHFromQFE returns the setting that makes the altimeter read the field elevation (ft) on the
+// ground, given the pressure measured there. QFEFromQNH is the reverse.
+func QNHFromQFE(qfeInHg, fieldElevation float64) float64 {
+	return StandardPressure(StandardHeightFromBarometricPressure(qfeInHg) - fieldElevation)
+}
+func QFEFromQNH(qnhInHg, fieldElevation float64) float64 {
+	return StandardPressure(StandardHeightFromBarometricPressure(qnhInHg) + fieldElevation)
+}
+
+// FlightLevelToIndicatedAltitude returns what an altimeter set to inHg would read at the flight
+// level (e.g. 180 for FL180).
+func FlightLevelToIndicatedAltitude(fl, inHg float64) float64 {
+	return PressureAltitudeToIndicatedAltitude(fl * 100.0, inHg)
+}
+
+// IndicatedAltitudeToFlightLevel is the (fractional) flight level for an altimeter reading.
+func IndicatedAltitudeToFlightLevel(indicatedAlt, inHg float64) float64 {
+	return IndicatedAltitudeToPressureAltitude(indicatedAlt, inHg) / 100.0
+}
+
+// NearestFlightLevel rounds a pressure altitude to the nearest whole flight level.
+func NearestFlightLevel(pressureAlt float64) int { return int(math.Floor(pressureAlt/100.0 + 0.5)) }
+
+// ReportedAltitudeToPressureAltitude converts an altitude reported by a pilot into a pressure
+// altitude, so that it can be compared with ADS-B. Reports at or above the transition
+// altitude are assumed to be flight levels, and so are already pressure altitudes.
+func ReportedAltitudeToPressureAltitude(reportedAlt, inHg, transitionAlt float64) float64 {
+	if reportedAlt >= transitionAlt { return reportedAlt }
+	return IndicatedAltitudeToPressureAltitude(reportedAlt, inHg)
+}
+
+// PressureAltitudeToReportedAltitude is the reverse; pressure altitudes that put the aircraft at
+// or above the transition altitude are left as flight levels.
+func PressureAltitudeToReportedAltitude(pressureAlt, inHg, transitionAlt float64) float64 {
+	if indicated := PressureAltitudeToIndicatedAltitude(pressureAlt, inHg); indicated < transitionAlt {
+		return indicated
+	}
+	return pressureAlt
+}
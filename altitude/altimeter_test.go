@@ -0,0 +1,96 @@
+package altitude
+
+import(
+	"math"
+	"testing"
+)
+
+func TestAltimeterSettings(t *testing.T) {
+	if hPa := InchesHgToHPa(StandardSettingInHg); math.Abs(hPa - StandardSettingHPa) > 0.05 {
+		t.Errorf("standard setting in hPa: expected %.2f, saw %.2f", StandardSettingHPa, hPa)
+	}
+	if inHg := HPaToInchesHg(InchesHgToHPa(30.12)); math.Abs(inHg - 30.12) > 1e-9 {
+		t.Errorf("hPa round trip: saw %f", inHg)
+	}
+
+	tests := []struct{
+		PressureAlt, InHg float64
+		Indicated         float64 // Rule of thumb: 0.1inHg is ~100ft
+	}{
+		{5000, StandardSettingInHg, 5000},
+		{5000, 30.12,               5185},
+		{5000, 29.72,               4815},
+	}
+	for i,test := range tests {
+		ind := PressureAltitudeToIndicatedAltitude(test.PressureAlt, test.InHg)
+		if math.Abs(ind - test.Indicated) > 10 {
+			t.Errorf("[%d] indicated: expected ~%.0f, saw %.0f", i, test.Indicated, ind)
+		}
+		if pa := IndicatedAltitudeToPressureAltitude(ind, test.InHg); math.Abs(pa - test.PressureAlt) > 1e-6 {
+			t.Errorf("[%d] pressure alt: expected %.0f, saw %f", i, test.PressureAlt, pa)
+		}
+		hPa := InchesHgToHPa(test.InHg)
+		if a := PressureAltitudeToIndicatedAltitudeHPa(test.PressureAlt, hPa); math.Abs(a-ind) > 1e-6 {
+			t.Errorf("[%d] hPa indicated: expected %.0f, saw %f", i, ind, a)
+		}
+		if a := IndicatedAltitudeToPressureAltitudeHPa(ind, hPa); math.Abs(a-test.PressureAlt) > 1e-6 {
+			t.Errorf("[%d] hPa pressure alt: expected %.0f, saw %f", i, test.PressureAlt, a)
+		}
+	}
+}
+
+func TestQNHQFE(t *testing.T) {
+	// A field at 1000ft; QFE is the pressure there
+	qnh, elev := 30.12, 1000.0
+	qfe := QFEFromQNH(qnh, elev)
+	if qfe >= qnh || math.Abs((qnh-qfe) - 1.07) > 0.05 {
+		t.Errorf("QFE: expected ~%.2f, saw %.2f", qnh-1.07, qfe)
+	}
+	if back := QNHFromQFE(qfe, elev); math.Abs(back - qnh) > 1e-6 {
+		t.Errorf("QNH round trip: expected %.2f, saw %f", qnh, back)
+	}
+
+	// With QNH set, the altimeter should read field elevation on the ground ...
+	pa := StandardHeightFromBarometricPressure(qfe)
+	if alt := PressureAltitudeToIndicatedAltitude(pa, qnh); math.Abs(alt - elev) > 1e-6 {
+		t.Errorf("altimeter on QNH: expected %.0f, saw %f", elev, alt)
+	}
+	// ... and zero with QFE set
+	if alt := PressureAltitudeToIndicatedAltitude(pa, qfe); math.Abs(alt) > 1e-6 {
+		t.Errorf("altimeter on QFE: expected 0, saw %f", alt)
+	}
+}
+
+func TestFlightLevels(t *testing.T) {
+	if fl := IndicatedAltitudeToFlightLevel(FlightLevelToIndicatedAltitude(180, 29.5), 29.5); math.Abs(fl-180) > 1e-9 {
+		t.Errorf("FL round trip: saw %f", fl)
+	}
+	if fl := NearestFlightLevel(IndicatedAltitudeToPressureAltitude(17800, 30.12)); fl != 176 {
+		t.Errorf("NearestFlightLevel: expected 176, saw %d", fl)
+	}
+
+	tests := []struct{
+		Reported, InHg, Pressure float64
+	}{
+		{18000, 30.12, 18000},        // A flight level
+		{25000, 29.12, 25000},
+		{10000, StandardSettingInHg, 10000},
+	}
+	for i,test := range tests {
+		if pa := ReportedAltitudeToPressureAltitude(test.Reported, test.InHg, KUSTransitionAltitude); math.Abs(pa - test.Pressure) > 1e-6 {
+			t.Errorf("[%d] pressure alt: expected %.0f, saw %f", i, test.Pressure, pa)
+		}
+		if r := PressureAltitudeToReportedAltitude(test.Pressure, test.InHg, KUSTransitionAltitude); math.Abs(r - test.Reported) > 1e-6 {
+			t.Errorf("[%d] reported: expected %.0f, saw %f", i, test.Reported, r)
+		}
+	}
+
+	// Below the transition altitude, the setting applies
+	pa := ReportedAltitudeToPressureAltitude(5000, 30.12, KUSTransitionAltitude)
+	if math.Abs(pa - 4815) > 10 {
+		t.Errorf("pressure alt below transition: expected ~4815, saw %.0f", pa)
+	}
+	if r := PressureAltitudeToReportedAltitude(pa, 30.12, KUSTransitionAltitude); math.Abs(r - 5000) > 1e-6 {
+		t.Errorf("reported below transition: expected 5000, saw %f", r)
+	}
+}
@@ -46,9 +46,14 @@ func PressureAltitudeToIndicatedAltitude(pressureAlt, inHg float64) float64 {
 	return pressureAlt - StandardHeightFromBarometricPressure(inHg)
 }
 
+// StandardHeightFromBarometricPressure handles all the layers of the standard atmosphere (see
+// isa.go); below 36,000ft, it reduces to the formula derived below.
 func StandardHeightFromBarometricPressure(bp float64) float64 {
-	//   h = T/-L . ( 1 - (BP/P)^(1/-E) )
-	return T0OverMinusL0 * (1.0 - math.Pow(bp/p0, OneOverMinusE))
+	if bp >= isaLayers[1].P {
+		//   h = T/-L . ( 1 - (BP/P)^(1/-E) )
+		return T0OverMinusL0 * (1.0 - math.Pow(bp/p0, OneOverMinusE))
+	}
+	return StandardHeightFromPressure(bp)
 }
 
 /* Derivation
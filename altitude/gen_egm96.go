@@ -0,0 +1,57 @@
+//go:build ignore
+// +build ignore
+
+// gen_egm96 writes egm96_grid.go, a 1deg decimation of the EGM96 geoid. Its input is the NGA's
+// 15' grid of EGM96 geoid undulations (WW15MGH.GRD, in metres), from https://earth-info.nga.mil/
+// (under Geodesy, EGM96). Run it via go generate, with WW15MGH.GRD in this directory.
+package main
+
+import(
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+
+	"github.com/skypies/geo/altitude"
+)
+
+func main() {
+	in := flag.String("in", "WW15MGH.GRD", "the NGA grid file")
+	out := flag.String("out", "egm96_grid.go", "the Go file to write")
+	n := flag.Int("decimate", 4, "keep every nth sample; 4 turns 15' into 1deg")
+	flag.Parse()
+
+	f,err := os.Open(*in)
+	if err != nil { log.Fatal(err) }
+	defer f.Close()
+	g,err := altitude.LoadGeoidGrid("EGM96", f)
+	if err != nil { log.Fatal(err) }
+	if g,err = g.Decimate(*n); err != nil { log.Fatal(err) }
+
+	o,err := os.Create(*out)
+	if err != nil { log.Fatal(err) }
+	w := bufio.NewWriter(o)
+
+	// The undulations are all well within +/-3276.7m, so store them as decimetres
+	fmt.Fprintf(w, "// Code generated by gen_egm96.go from %s; DO NOT EDIT.\n\n", *in)
+	fmt.Fprintf(w, "package altitude\n\n")
+	fmt.Fprintf(w, "func init() {\n")
+	fmt.Fprintf(w, "\tn := make([]float64, len(kEGM96Decimetres))\n")
+	fmt.Fprintf(w, "\tfor i,dm := range kEGM96Decimetres { n[i] = float64(dm) / 10.0 }\n")
+	fmt.Fprintf(w, "\tkEGM96 = GeoidGrid{Name:%q, LatMin:%v, LongMin:%v, Step:%v, NLat:%d, NLong:%d, N:n}\n",
+		"EGM96 (1deg)", g.LatMin, g.LongMin, g.Step, g.NLat, g.NLong)
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "// From the south-west corner, going east then north\n")
+	fmt.Fprintf(w, "var kEGM96Decimetres = []int16{\n")
+	for i,v := range g.N {
+		if i % 16 == 0 { fmt.Fprintf(w, "\t") }
+		fmt.Fprintf(w, "%d,", int16(math.Round(v * 10)))
+		if i % 16 == 15 || i == len(g.N)-1 { fmt.Fprintf(w, "\n") } else { fmt.Fprintf(w, " ") }
+	}
+	fmt.Fprintf(w, "}\n")
+
+	if err := w.Flush(); err != nil { log.Fatal(err) }
+	if err := o.Close(); err != nil { log.Fatal(err) }
+}
@@ -0,0 +1,201 @@
+package altitude
+// GNSS receivers report height above the WGS84 ellipsoid (HAE). Mean sea level follows the
+// geoid, which lies above or below the ellipsoid by the 'undulation', N:
+//   MSL = HAE - N
+// In the Bay Area, N is about -32m; so a GNSS altitude reads ~105ft lower than MSL.
+// https://en.wikipedia.org/wiki/Geoid
+
+import(
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const FeetPerMetre = 3.28084
+
+// GeoidGrid holds undulations (in metres) on a regular lat/long grid.
+type GeoidGrid struct {
+	Name            string
+	LatMin,LongMin  float64   // The south-west corner
+	Step            float64   // Grid spacing, in degrees
+	NLat,NLong      int
+	N             []float64   // Row major, from the south-west corner, going east then north
+}
+
+func (g GeoidGrid)String() string {
+	return fmt.Sprintf("Geoid %s: %dx%d @%.2fdeg from (%.2f,%.2f)", g.Name, g.NLat, g.NLong,
+		g.Step, g.LatMin, g.LongMin)
+}
+
+func (g GeoidGrid)isGlobal() bool { return float64(g.NLong-1) * g.Step >= 359.99 }
+
+// Covers is true if the grid has data for the position (global grids cover everywhere).
+func (g GeoidGrid)Covers(lat, long float64) bool {
+	latMax := g.LatMin + float64(g.NLat-1)*g.Step
+	if lat < g.LatMin || lat > latMax { return false }
+	if g.isGlobal() { return true }
+	return long >= g.LongMin && long <= g.LongMin + float64(g.NLong-1)*g.Step
+}
+
+func (g GeoidGrid)at(i,j int) float64 { return g.N[i*g.NLong + j] }
+
+// Undulation interpolates N (metres) bilinearly at the position. The bool is false if the grid
+// does not cover the position.
+func (g GeoidGrid)Undulation(lat, long float64) (float64, bool) {
+	if !g.Covers(lat, long) { return 0, false }
+	if g.isGlobal() {
+		long = math.Mod(long - g.LongMin + 720.0, 360.0) + g.LongMin
+	}
+
+	// Fractional indices into the grid, clamped to the edges (against rounding)
+	clamp := func(x float64, n int) float64 { return math.Max(0, math.Min(x, float64(n-1))) }
+	y := clamp((lat  - g.LatMin)  / g.Step, g.NLat)
+	x := clamp((long - g.LongMin) / g.Step, g.NLong)
+
+	i,j := int(math.Floor(y)), int(math.Floor(x))
+	if i == g.NLat-1 && i > 0 { i-- }
+	if j == g.NLong-1 && j > 0 { j-- }
+	fy,fx := y - float64(i), x - float64(j)
+
+	i1,j1 := i+1, j+1
+	if i1 >= g.NLat { i1 = i }
+	if j1 >= g.NLong { j1 = j }
+
+	south := g.at(i,j)  *(1-fx) + g.at(i,j1) *fx
+	north := g.at(i1,j) *(1-fx) + g.at(i1,j1)*fx
+	return south*(1-fy) + north*fy, true
+}
+
+// HAEToMSL converts a height above the ellipsoid into a height above mean sea level (both in
+// feet), at the given position. The bool is false if the grid does not cover the position.
+func (g GeoidGrid)HAEToMSL(haeFeet, lat, long float64) (float64, bool) {
+	n,ok := g.Undulation(lat, long)
+	return haeFeet - n * FeetPerMetre, ok
+}
+
+func (g GeoidGrid)MSLToHAE(mslFeet, lat, long float64) (float64, bool) {
+	n,ok := g.Undulation(lat, long)
+	return mslFeet + n * FeetPerMetre, ok
+}
+
+// LoadGeoidGrid reads a grid in the NGA format used for EGM96 (WW15MGH.GRD) and EGM2008. The
+// header is "latMin latMax longMin longMax dLat dLong", followed by the values row by row,
+// starting in the north-west and going east then south.
+func LoadGeoidGrid(name string, r io.Reader) (GeoidGrid, error) {
+	vals := []float64{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		for _,s := range strings.Fields(scanner.Text()) {
+			f,err := strconv.ParseFloat(s, 64)
+			if err != nil { return GeoidGrid{}, fmt.Errorf("geoid %s: bad value '%s'", name, s) }
+			vals = append(vals, f)
+		}
+	}
+	if err := scanner.Err(); err != nil { return GeoidGrid{}, err }
+	if len(vals) < 6 { return GeoidGrid{}, fmt.Errorf("geoid %s: no header", name) }
+
+	latMin,latMax,longMin,longMax,dLat,dLong := vals[0],vals[1],vals[2],vals[3],vals[4],vals[5]
+	if dLat != dLong || dLat <= 0 {
+		return GeoidGrid{}, fmt.Errorf("geoid %s: need equal, positive spacings", name)
+	}
+	g := GeoidGrid{
+		Name: name,
+		LatMin: latMin,
+		LongMin: longMin,
+		Step: dLat,
+		NLat: int(math.Floor((latMax-latMin)/dLat + 0.5)) + 1,
+		NLong: int(math.Floor((longMax-longMin)/dLong + 0.5)) + 1,
+	}
+
+	rows := vals[6:]
+	if len(rows) != g.NLat*g.NLong {
+		return GeoidGrid{}, fmt.Errorf("geoid %s: expected %d values, saw %d", name,
+			g.NLat*g.NLong, len(rows))
+	}
+	// Flip the rows, so that we run south to north
+	for i:=g.NLat-1; i>=0; i-- {
+		g.N = append(g.N, rows[i*g.NLong : (i+1)*g.NLong]...)
+	}
+	return g, nil
+}
+
+// Decimate keeps every nth sample in each direction; e.g. 4 turns a 15' grid into a 1deg grid.
+// The grid must divide evenly.
+func (g GeoidGrid)Decimate(n int) (GeoidGrid, error) {
+	if n < 1 || (g.NLat-1) % n != 0 || (g.NLong-1) % n != 0 {
+		return GeoidGrid{}, fmt.Errorf("geoid %s: can't decimate %dx%d by %d", g.Name, g.NLat, g.NLong, n)
+	}
+	d := GeoidGrid{
+		Name: fmt.Sprintf("%s/%d", g.Name, n),
+		LatMin: g.LatMin,
+		LongMin: g.LongMin,
+		Step: g.Step * float64(n),
+		NLat: (g.NLat-1)/n + 1,
+		NLong: (g.NLong-1)/n + 1,
+	}
+	for i:=0; i<g.NLat; i+=n {
+		for j:=0; j<g.NLong; j+=n { d.N = append(d.N, g.at(i,j)) }
+	}
+	return d, nil
+}
+
+// kEGM96 is the global grid, decimated to 1deg from the NGA's 15' EGM96 grid; it is set by
+// egm96_grid.go, which gen_egm96.go generates from WW15MGH.GRD. It is empty until then.
+//go:generate go run gen_egm96.go -in WW15MGH.GRD -out egm96_grid.go
+var kEGM96 GeoidGrid
+
+// EGM96 returns a copy of the built-in global grid; the bool is false if it was not generated.
+func EGM96() (GeoidGrid, bool) {
+	if kEGM96.NLat == 0 { return GeoidGrid{}, false }
+	g := kEGM96
+	g.N = append([]float64{}, kEGM96.N...)
+	return g, true
+}
+
+// kNorCalGeoid is a coarse (1deg) approximation to EGM96 over northern & central California
+// (35-40N, 119-125W), used only if the global grid was not generated. The values are
+// approximate, eyeballed from EGM96 maps (N is about -32m at KSFO); they are not samples of the
+// NGA grid, and may be out by a few metres.
+var kNorCalGeoid = GeoidGrid{
+	Name: "NorCal (approx EGM96)",
+	LatMin: 35.0,
+	LongMin: -125.0,
+	Step: 1.0,
+	NLat: 6,
+	NLong: 7,
+	N: []float64{
+	// -125   -124   -123   -122   -121   -120   -119
+		-37.0, -36.5, -36.0, -35.5, -34.5, -33.0, -31.0, // 35N
+		-36.0, -35.5, -34.8, -34.0, -33.0, -31.5, -29.5, // 36N
+		-34.5, -33.8, -33.0, -32.3, -31.3, -29.8, -28.0, // 37N
+		-33.0, -32.2, -31.3, -30.5, -29.5, -28.0, -26.5, // 38N
+		-31.5, -30.6, -29.6, -28.8, -27.8, -26.5, -25.0, // 39N
+		-30.0, -29.0, -28.0, -27.2, -26.2, -25.0, -23.5, // 40N
+	},
+}
+
+// NorCalGeoid returns a copy of the built-in grid; see kNorCalGeoid for its limits.
+func NorCalGeoid() GeoidGrid {
+	g := kNorCalGeoid
+	g.N = append([]float64{}, kNorCalGeoid.N...)
+	return g
+}
+
+// builtinGeoid is the global grid if it was generated, or else the NorCal grid.
+func builtinGeoid() *GeoidGrid {
+	if kEGM96.NLat > 0 { return &kEGM96 }
+	return &kNorCalGeoid
+}
+
+// HAEToMSL converts using the built-in grid (see builtinGeoid); the bool is false outside it.
+func HAEToMSL(haeFeet, lat, long float64) (float64, bool) {
+	return builtinGeoid().HAEToMSL(haeFeet, lat, long)
+}
+
+// MSLToHAE converts using the built-in grid (see builtinGeoid); the bool is false outside it.
+func MSLToHAE(mslFeet, lat, long float64) (float64, bool) {
+	return builtinGeoid().MSLToHAE(mslFeet, lat, long)
+}
@@ -0,0 +1,108 @@
+package altitude
+
+import(
+	"math"
+	"strings"
+	"testing"
+)
+
+// A tiny global grid, at 90deg spacing, in the NGA file format
+var testGeoidFile = `
+-90.0 90.0 0.0 360.0 90.0 90.0
+  10.0  10.0  10.0  10.0  10.0
+   0.0  20.0  40.0  20.0   0.0
+ -10.0 -10.0 -10.0 -10.0 -10.0
+`
+
+func TestGeoidGrid(t *testing.T) {
+	g,err := LoadGeoidGrid("test", strings.NewReader(testGeoidFile))
+	if err != nil { t.Fatalf("LoadGeoidGrid: %v", err) }
+	if g.NLat != 3 || g.NLong != 5 || g.LatMin != -90 { t.Fatalf("bad grid: %s", g) }
+
+	tests := []struct{
+		Lat,Long float64
+		Expected float64
+	}{
+		{  90,    0,  10},
+		{ -90,    0, -10},
+		{   0,   90,  20},
+		{   0,  180,  40},
+		{   0,  135,  30},  // Halfway along the equator
+		{  45,  180,  25},  // Halfway north
+		{  45,  135,  20},  // Both
+		{   0,  -90,  20},  // Wraps round to 270
+		{   0,  -45,  10},
+	}
+	for i,test := range tests {
+		if actual,ok := g.Undulation(test.Lat, test.Long); !ok || math.Abs(actual - test.Expected) > 1e-9 {
+			t.Errorf("[%d] (%.0f,%.0f): expected %.2f, saw %.2f", i, test.Lat, test.Long,
+				test.Expected, actual)
+		}
+	}
+
+	if _,err := LoadGeoidGrid("short", strings.NewReader("0 1 0 1 1 1\n1 2 3\n")); err == nil {
+		t.Errorf("expected an error for a short grid")
+	}
+}
+
+func TestHAEToMSL(t *testing.T) {
+	g := NorCalGeoid()
+	lat,long := 37.6188, -122.3754 // KSFO; EGM96 N is about -32m
+	if !g.Covers(lat, long) || g.Covers(51.5, 0) { t.Errorf("Covers is wrong") }
+
+	if n,ok := g.Undulation(lat, long); !ok || n > -30 || n < -34 {
+		t.Errorf("undulation at KSFO: expected ~-32m, saw %.1f (%v)", n, ok)
+	}
+	msl,ok := HAEToMSL(1000, lat, long)
+	if !ok || msl < 1095 || msl > 1115 {
+		t.Errorf("HAEToMSL: expected ~1105, saw %.0f (%v)", msl, ok)
+	}
+	if hae,ok := MSLToHAE(msl, lat, long); !ok || math.Abs(hae - 1000) > 1e-9 {
+		t.Errorf("MSLToHAE: expected 1000, saw %f (%v)", hae, ok)
+	}
+
+	// Outside the grid, there is no answer (rather than the value at the nearest edge)
+	for _,pos := range [][2]float64{{51.5, 0}, {37.6, -126}, {41, -122}} {
+		if _,ok := g.HAEToMSL(1000, pos[0], pos[1]); ok {
+			t.Errorf("HAEToMSL%v: expected not ok outside the grid", pos)
+		}
+	}
+
+	// The copy can't be used to change the built-in grid
+	g.N[0] = 1000
+	if n,_ := NorCalGeoid().Undulation(35, -125); n != -37 { t.Errorf("built-in grid was modified") }
+}
+
+func TestDecimate(t *testing.T) {
+	g,_ := LoadGeoidGrid("test", strings.NewReader(testGeoidFile))
+	d,err := g.Decimate(2)
+	if err != nil || d.NLat != 2 || d.NLong != 3 || d.Step != 180 { t.Fatalf("Decimate: saw %s (%v)", d, err) }
+	if n,_ := d.Undulation(-90, 180); n != -10 { t.Errorf("Decimate: expected -10 at the south pole, saw %.1f", n) }
+	if _,err := g.Decimate(3); err == nil { t.Errorf("Decimate: expected an error for an uneven grid") }
+}
+
+// The NGA's published EGM96 test values (from the F477 interpolation program's test data).
+func TestEGM96(t *testing.T) {
+	g,ok := EGM96()
+	if !ok { t.Skip("no global grid; run go generate in altitude, with the NGA's WW15MGH.GRD") }
+
+	tests := []struct{
+		Lat,Long,Expected float64
+	}{
+		{ 38.6281550, 269.7791550, -31.628},
+		{-14.6212170, 305.0211140,  -2.969},
+		{ 46.8743190, 102.4487290, -43.575},
+		{-23.6174460, 133.8747120,  15.871},
+		{ 38.6254730, 359.9995000,  50.066},
+		{ -0.4667440,   0.0023000,  17.329},
+	}
+	for i,test := range tests {
+		// The built-in grid is only at 1deg, and we interpolate bilinearly
+		if n,ok := g.Undulation(test.Lat, test.Long); !ok || math.Abs(n - test.Expected) > 1.5 {
+			t.Errorf("[%d] (%.2f,%.2f): expected %.3fm, saw %.3fm", i, test.Lat, test.Long, test.Expected, n)
+		}
+		if _,ok := HAEToMSL(1000, test.Lat, test.Long - 360); !ok {
+			t.Errorf("[%d] HAEToMSL: not ok with the global grid", i)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package altitude
+// The full 1976 US Standard Atmosphere, from sea level up to 84,852m (278,386ft). Each layer
+// has a constant lapse rate; the base pressures are derived from the layers below, so that
+// pressure is continuous across the layer boundaries.
+// https://en.wikipedia.org/wiki/U.S._Standard_Atmosphere
+// https://en.wikipedia.org/wiki/Barometric_formula
+
+import "math"
+
+const (
+	// For working in SI units
+	PascalsPerInHg = 3386.389
+	RstarSI        = 8.3144598  // Universal gas constant    (J/(mol.K))
+	MSI            = 0.0289644  // Molar mass of Earth's air (kg/mol)
+
+	// Earth's radius, as used in the standard to convert between geopotential and geometric
+	// altitudes (6,356,766m)
+	R0 = 20855531.5             // (ft)
+
+	// This combination appears in the barometric formula for isothermal layers
+	GzeroMOverRstar = (Gzero * M) / Rstar // (K/ft)
+)
+
+type isaLayer struct {
+	Base  float64 // Geopotential altitude at the base of the layer (ft)
+	T     float64 // Temperature at the base of the layer (K)
+	L     float64 // Temperature lapse rate (K/ft)
+	P     float64 // Pressure at the base of the layer (inHg)
+}
+
+// The layers, b=0 through b=6. The base pressures are filled in by init.
+var isaLayers = []isaLayer{
+	{     0.0, T0,      L0,          p0},
+	{ 36089.24, 216.65,  0.0,        0},
+	{ 65616.80, 216.65,  0.0003048,  0},
+	{104986.88, 228.65,  0.00085344, 0},
+	{154199.48, 270.65,  0.0,        0},
+	{167322.83, 270.65, -0.00085344, 0},
+	{232939.63, 214.65, -0.0006096,  0},
+}
+
+func init() {
+	for b:=1; b<len(isaLayers); b++ {
+		prev := isaLayers[b-1]
+		isaLayers[b].P = prev.pressureAt(isaLayers[b].Base)
+	}
+}
+
+// layerForHeight returns the layer containing the geopotential altitude. Altitudes below sea
+// level use b=0, and those above the top of the model use the top layer.
+func layerForHeight(h float64) isaLayer {
+	for b:=len(isaLayers)-1; b>0; b-- {
+		if h >= isaLayers[b].Base { return isaLayers[b] }
+	}
+	return isaLayers[0]
+}
+
+// layerForPressure is like layerForHeight, but keyed on pressure (which falls with height).
+func layerForPressure(inHg float64) isaLayer {
+	for b:=len(isaLayers)-1; b>0; b-- {
+		if inHg <= isaLayers[b].P { return isaLayers[b] }
+	}
+	return isaLayers[0]
+}
+
+func (l isaLayer)temperatureAt(h float64) float64 { return l.T + l.L*(h-l.Base) }
+
+func (l isaLayer)pressureAt(h float64) float64 {
+	if l.L == 0.0 {
+		//   P = Pb . exp( -g0.M.(h-hb) / R*.Tb )
+		return l.P * math.Exp(-GzeroMOverRstar * (h-l.Base) / l.T)
+	}
+	//   P = Pb . ( Tb / (Tb + Lb(h-hb)) ) ^ (g0.M / R*.Lb)
+	return l.P * math.Pow(l.T / l.temperatureAt(h), GzeroMOverRstar / l.L)
+}
+
+func (l isaLayer)heightForPressure(inHg float64) float64 {
+	if l.L == 0.0 {
+		return l.Base - (l.T / GzeroMOverRstar) * math.Log(inHg/l.P)
+	}
+	// This is the derivation in altitude.go, generalized to any base height
+	return l.Base + (l.T/l.L) * (math.Pow(inHg/l.P, -l.L/GzeroMOverRstar) - 1.0)
+}
+
+// densityAt is in kg/m^3
+func (l isaLayer)densityAt(h float64) float64 {
+	return densityFromPressureTemperature(l.pressureAt(h), l.temperatureAt(h))
+}
+
+func densityFromPressureTemperature(inHg, kelvin float64) float64 {
+	return (inHg * PascalsPerInHg * MSI) / (RstarSI * kelvin)
+}
+
+// StandardTemperature is the temperature (K) at the given geopotential altitude (ft).
+func StandardTemperature(h float64) float64 { return layerForHeight(h).temperatureAt(h) }
+
+// StandardPressure is the static pressure (inHg) at the given geopotential altitude (ft).
+func StandardPressure(h float64) float64 { return layerForHeight(h).pressureAt(h) }
+
+// StandardDensity is the air density (kg/m^3) at the given geopotential altitude (ft).
+func StandardDensity(h float64) float64 { return layerForHeight(h).densityAt(h) }
+
+// StandardDensityRatio is the density at the altitude, relative to that at sea level (sigma).
+func StandardDensityRatio(h float64) float64 { return StandardDensity(h) / StandardDensity(0) }
+
+// StandardHeightFromPressure is the geopotential altitude (ft) at which the standard atmosphere
+// has the given static pressure (inHg); i.e. the pressure altitude.
+func StandardHeightFromPressure(inHg float64) float64 {
+	return layerForPressure(inHg).heightForPressure(inHg)
+}
+
+// StandardHeightFromDensity is the geopotential altitude (ft) at which the standard atmosphere
+// has the given density (kg/m^3). Density falls monotonically with height, so this is unique.
+func StandardHeightFromDensity(rho float64) float64 {
+	l := isaLayers[0]
+	for b:=len(isaLayers)-1; b>0; b-- {
+		if rho <= isaLayers[b].densityAt(isaLayers[b].Base) { l = isaLayers[b]; break }
+	}
+
+	rhoB := l.densityAt(l.Base)
+	if l.L == 0.0 {
+		// rho/rhob = P/Pb, as the temperature is constant
+		return l.Base - (l.T / GzeroMOverRstar) * math.Log(rho/rhoB)
+	}
+	//   rho/rhob = (Tb/T) ^ (g0.M/R*.Lb + 1)
+	t := l.T * math.Pow(rho/rhoB, -1.0 / (GzeroMOverRstar/l.L + 1.0))
+	return l.Base + (t - l.T)/l.L
+}
+
+// The standard atmosphere is defined in terms of geopotential altitude, which accounts for
+// gravity weakening with height. Below 65,000ft, they differ by less than 0.4%.
+func GeometricToGeopotential(z float64) float64 { return R0 * z / (R0 + z) }
+func GeopotentialToGeometric(h float64) float64 { return R0 * h / (R0 - h) }
@@ -0,0 +1,57 @@
+package altitude
+
+import(
+	"math"
+	"testing"
+)
+
+// Values from the 1976 US Standard Atmosphere tables, converted to feet & inHg.
+func TestStandardAtmosphere(t *testing.T) {
+	tests := []struct{
+		H        float64 // geopotential, ft
+		T        float64 // K
+		P        float64 // inHg
+		Rho      float64 // kg/m^3
+	}{
+		{     0, 288.15, 29.9213, 1.2250},
+		{ 10000, 268.34, 20.5769, 0.9046},
+		{ 36089, 216.65,  6.6832, 0.3639},
+		{ 50000, 216.65,  3.4247, 0.1865},
+		{ 65617, 216.65,  1.6167, 0.0880},
+		{ 80000, 221.03,  0.8157, 0.0435},
+	}
+
+	for i,test := range tests {
+		if actual := StandardTemperature(test.H); math.Abs(actual - test.T) > 0.05 {
+			t.Errorf("[%d] T(%.0f): expected %.2f, saw %.2f", i, test.H, test.T, actual)
+		}
+		if actual := StandardPressure(test.H); math.Abs(actual - test.P) > 0.005 {
+			t.Errorf("[%d] P(%.0f): expected %.4f, saw %.4f", i, test.H, test.P, actual)
+		}
+		if actual := StandardDensity(test.H); math.Abs(actual - test.Rho) > 0.001 {
+			t.Errorf("[%d] rho(%.0f): expected %.4f, saw %.4f", i, test.H, test.Rho, actual)
+		}
+	}
+}
+
+func TestStandardAtmosphereInverses(t *testing.T) {
+	for h:=-1000.0; h<=100000.0; h+=2500.0 {
+		if actual := StandardHeightFromPressure(StandardPressure(h)); math.Abs(actual-h) > 0.1 {
+			t.Errorf("height from pressure at %.0f: saw %.2f", h, actual)
+		}
+		if actual := StandardHeightFromDensity(StandardDensity(h)); math.Abs(actual-h) > 0.1 {
+			t.Errorf("height from density at %.0f: saw %.2f", h, actual)
+		}
+		if actual := GeometricToGeopotential(GeopotentialToGeometric(h)); math.Abs(actual-h) > 0.01 {
+			t.Errorf("geometric/geopotential at %.0f: saw %.2f", h, actual)
+		}
+	}
+
+	// Below the tropopause, the general version should agree with the original formula
+	if a,b := StandardHeightFromBarometricPressure(29.82), StandardHeightFromPressure(29.82); math.Abs(a-b) > 0.5 {
+		t.Errorf("tropospheric heights differ: %.2f vs %.2f", a, b)
+	}
+	if h := StandardHeightFromBarometricPressure(StandardPressure(45000)); math.Abs(h-45000) > 0.1 {
+		t.Errorf("stratospheric height: expected 45000, saw %.2f", h)
+	}
+}
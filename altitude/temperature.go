@@ -0,0 +1,46 @@
+package altitude
+// When the air is warmer or colder than the standard atmosphere, altitudes derived from pressure
+// (even with the right altimeter setting) are wrong; the column of air below the aircraft is
+// expanded or compressed. This matters for aircraft performance (density altitude), and for
+// terrain clearance in cold weather (true altitude).
+
+import "math"
+
+func CelsiusToKelvin(c float64) float64 { return c + 273.15 }
+func KelvinToCelsius(k float64) float64 { return k - 273.15 }
+
+// ISADeviation is how much warmer (in K, or C) the air is than standard, at the altitude.
+func ISADeviation(pressureAlt, oatCelsius float64) float64 {
+	return CelsiusToKelvin(oatCelsius) - StandardTemperature(pressureAlt)
+}
+
+// DensityAltitude is the height in the standard atmosphere which has the same air density as
+// the actual air, given the pressure altitude and the outside air temperature.
+func DensityAltitude(pressureAlt, oatCelsius float64) float64 {
+	// The density ratio, sigma = (P/P0) . (T0/T)
+	sigma := (StandardPressure(pressureAlt) / p0) * (T0 / CelsiusToKelvin(oatCelsius))
+
+	// Below the tropopause, sigma = (T/T0)^(-E-1) = (1 + L.h/T)^(-E-1), so
+	//   h = T/-L . ( 1 - sigma^(1/(-E-1)) )
+	if h := T0OverMinusL0 * (1.0 - math.Pow(sigma, 1.0/(-E-1.0))); h < isaLayers[1].Base {
+		return h
+	}
+	return StandardHeightFromDensity(sigma * StandardDensity(0))
+}
+
+// ColdTemperatureCorrection is the ICAO correction (PANS-OPS, Doc 8168), in feet, to add to a
+// height above the aerodrome, given the aerodrome's elevation and temperature. It is positive
+// when the aerodrome is colder than standard. Assuming the temperature deviation holds all the
+// way up, it is exactly the error in an altimeter set to the aerodrome's QNH:
+//   dH = (-dT/L0) . ln( 1 + L0.h / (T0 + L0.elev) )
+func ColdTemperatureCorrection(heightAboveAerodrome, aerodromeElevation, aerodromeTempCelsius float64) float64 {
+	dT := ISADeviation(aerodromeElevation, aerodromeTempCelsius)
+	return (-dT/L0) * math.Log(1.0 + L0*heightAboveAerodrome / (T0 + L0*aerodromeElevation))
+}
+
+// TrueAltitude is the actual height above sea level of an aircraft whose altimeter (set to the
+// aerodrome's QNH) reads indicatedAlt.
+func TrueAltitude(indicatedAlt, aerodromeElevation, aerodromeTempCelsius float64) float64 {
+	h := indicatedAlt - aerodromeElevation
+	return indicatedAlt - ColdTemperatureCorrection(h, aerodromeElevation, aerodromeTempCelsius)
+}
@@ -0,0 +1,56 @@
+package altitude
+
+import(
+	"math"
+	"testing"
+)
+
+func TestDensityAltitude(t *testing.T) {
+	tests := []struct{
+		PressureAlt, OATCelsius float64
+		Expected                float64 // Rule of thumb: 120ft per degree C above standard
+	}{
+		{    0,  15,     0},
+		{ 5000,   5,  5000},
+		{ 5000,  25,  7400},
+		{ 5000, -15,  2600},
+		{40000, -56.5, 40000},
+		{40000, -46.5, 40940}, // Isothermal layer; the rule of thumb doesn't hold
+	}
+	for i,test := range tests {
+		actual := DensityAltitude(test.PressureAlt, test.OATCelsius)
+		if math.Abs(actual - test.Expected) > 150 {
+			t.Errorf("[%d] DensityAltitude(%.0f, %.1fC): expected ~%.0f, saw %.0f", i,
+				test.PressureAlt, test.OATCelsius, test.Expected, actual)
+		}
+	}
+}
+
+func TestColdTemperatureCorrection(t *testing.T) {
+	tests := []struct{
+		Height, Elevation, TempC float64
+		Expected                 float64 // Roughly h.dT/T0, for low heights
+	}{
+		{ 1000,    0,  15,   0},
+		{ 1000,    0, -10,  87},
+		{ 1000,    0, -30, 156},
+		{ 3000,    0, -30, 468},
+		{ 1000,    0,  35, -69}, // A warm day; negative
+		{ 1000, 5000, -25, 104}, // ISA at 5000ft is 5.1C
+	}
+	for i,test := range tests {
+		actual := ColdTemperatureCorrection(test.Height, test.Elevation, test.TempC)
+		if math.Abs(actual - test.Expected) > 15 {
+			t.Errorf("[%d] correction(%.0f,%.0f,%.0fC): expected ~%.0f, saw %.0f", i,
+				test.Height, test.Elevation, test.TempC, test.Expected, actual)
+		}
+	}
+
+	// In the cold, the aircraft is lower than the altimeter says
+	if ta := TrueAltitude(3000, 500, -20); ta >= 3000 || ta < 2600 {
+		t.Errorf("TrueAltitude: expected a bit under 3000, saw %.0f", ta)
+	}
+	if ta := TrueAltitude(500, 500, -20); ta != 500 {
+		t.Errorf("TrueAltitude: expected 500 on the ground, saw %.0f", ta)
+	}
+}
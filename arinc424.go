@@ -0,0 +1,81 @@
+package geo
+
+// A reader for ARINC 424 navigation data; 132-column fixed-width records, as supplied by the
+// data vendors (and in the FAA's CIFP). Only the records for positions are read: enroute and
+// terminal waypoints, VHF and NDB navaids, and airports. Continuation records are skipped.
+
+import(
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var(
+	kARINCSection      = column{5, 1}
+	kARINCSubsection   = column{6, 1}   // For sections D and E
+	kARINCPSubsection  = column{13, 1}  // For section P (airports)
+	kARINCAirport      = column{7, 4}
+	kARINCIdent        = column{14, 5}  // Waypoints; navaids are only 4 chars, but are blank-padded
+	kARINCContinuation = column{22, 1}
+	kARINCLat          = column{33, 9}  // e.g. "N37103568"
+	kARINCLong         = column{42, 10} // e.g. "W122002995"
+	kARINCDMELat       = column{56, 9}  // VHF navaids with no VOR (e.g. a standalone DME)
+	kARINCDMELong      = column{65, 10}
+)
+
+// parseARINCCoord converts "N37103568" (hemisphere, degrees, minutes, seconds and hundredths)
+// into the FAA concatenated form ("371035.68N"), for parseCoord.
+func parseARINCCoord(s string) (float64, bool) {
+	if len(s) < 9 || !strings.ContainsAny(s[:1], "NSEW") { return 0, false }
+	if _,err := strconv.Atoi(s[1:]); err != nil { return 0, false }
+	digits := s[1:]
+	return parseCoordOK(digits[:len(digits)-2] + "." + digits[len(digits)-2:] + s[:1])
+}
+
+// parseARINCPos returns false if the position is missing or malformed.
+func parseARINCPos(line string, latCol, longCol column) (Latlong, bool) {
+	lat,latOK := parseARINCCoord(latCol.get(line))
+	long,longOK := parseARINCCoord(longCol.get(line))
+	return Latlong{lat, long}, latOK && longOK
+}
+
+// ParseARINC424 reads ARINC 424 records. Waypoints (enroute and terminal) are of type
+// "waypoint", VORs, DMEs and NDBs of type "navaid", and airports of type "airport". Terminal
+// waypoints are often defined at several airports (e.g. "RW28L"), so expect conflicts when
+// building a registry from the lot.
+func ParseARINC424(r io.Reader, source string) ([]FixEntry, error) {
+	ret := []FixEntry{}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "S") { continue } // Only standard records; not headers, or tailored
+		if cont := kARINCContinuation.get(line); cont != "0" && cont != "1" { continue }
+
+		fe := FixEntry{Source:source}
+		pos,ok := parseARINCPos(line, kARINCLat, kARINCLong)
+
+		switch sec,sub,psub := kARINCSection.get(line), kARINCSubsection.get(line), kARINCPSubsection.get(line); {
+		case sec == "E" && sub == "A", sec == "P" && psub == "C":
+			fe.Name,fe.Type = kARINCIdent.get(line), "waypoint"
+		case sec == "D" && (sub == "" || sub == "B"):
+			fe.Name,fe.Type = kARINCIdent.get(line), "navaid"
+			if kARINCLat.get(line) == "" { pos,ok = parseARINCPos(line, kARINCDMELat, kARINCDMELong) }
+		case sec == "P" && psub == "A":
+			fe.Name,fe.Type = kARINCAirport.get(line), "airport"
+		default:
+			continue
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("%s: line %d: bad position for %s", source, lineNum, fe.Name)
+		}
+		fe.Latlong = pos
+		ret = append(ret, fe)
+	}
+	if err := scanner.Err(); err != nil { return nil, fmt.Errorf("%s: %v", source, err) }
+	return ret, nil
+}
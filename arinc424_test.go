@@ -0,0 +1,66 @@
+package geo
+
+import(
+	"strings"
+	"testing"
+)
+
+var testARINC424 = strings.Join([]string{
+	"HDR01CIFP_1604.DAT",
+	fixedWidth(map[int]string{1:"SUSAEAENRT", 11:"K2", 14:"EPICK", 20:"K2", 22:"0", 27:"W", 33:"N37103568W122002995", 99:"EPICK"}),
+	fixedWidth(map[int]string{1:"SUSAEAENRT", 11:"K2", 14:"EPICK", 20:"K2", 22:"2", 23:"W"}),
+	fixedWidth(map[int]string{1:"SUSAD", 11:"K2", 14:"SJC", 20:"K2", 22:"1", 23:"11410", 33:"N37222902W121564080", 94:"SAN JOSE"}),
+	fixedWidth(map[int]string{1:"SUSAD", 11:"K2", 14:"IXYZ", 20:"K2", 22:"1", 56:"N37000000W122000000"}),
+	fixedWidth(map[int]string{1:"SUSADB", 11:"K2", 14:"OS", 20:"K2", 22:"1", 33:"N37393000W122250000"}),
+	fixedWidth(map[int]string{1:"SUSAP", 7:"KSFO", 11:"K2", 13:"A", 14:"SFO", 22:"0", 33:"N37370861W122222960", 94:"SAN FRANCISCO INTL"}),
+	fixedWidth(map[int]string{1:"SUSAP", 7:"KSFO", 11:"K2", 13:"C", 14:"RW28L", 20:"K2", 22:"0", 33:"N37364800W122212200"}),
+	fixedWidth(map[int]string{1:"SUSAP", 7:"KSJC", 11:"K2", 13:"C", 14:"RW28L", 20:"K2", 22:"0", 33:"N37214000W121554000"}),
+	fixedWidth(map[int]string{1:"SUSAP", 7:"KSFO", 11:"K2", 13:"D", 14:"SSTIK4", 20:"K2", 22:"0"}),
+}, "\n")
+
+func TestParseARINC424(t *testing.T) {
+	fes,err := ParseARINC424(strings.NewReader(testARINC424), "test.dat")
+	if err != nil { t.Fatal(err) }
+
+	expected := []struct{
+		Name,Type  string
+		Pos        Latlong
+	}{
+		{"EPICK", "waypoint", Latlong{37.1765778, -122.0083194}},
+		{"SJC",   "navaid",   Latlong{37.3747278, -121.9446667}},
+		{"IXYZ",  "navaid",   Latlong{37.0, -122.0}},  // A DME, with no VOR
+		{"OS",    "navaid",   Latlong{37.6583333, -122.4166667}},
+		{"KSFO",  "airport",  Latlong{37.6190583, -122.3748889}},
+		{"RW28L", "waypoint", Latlong{37.6133333, -122.3561111}},
+		{"RW28L", "waypoint", Latlong{37.3611111, -121.9277778}},
+	}
+	if len(fes) != len(expected) { t.Fatalf("expected %d entries, saw %d: %v", len(expected), len(fes), fes) }
+	for i,e := range expected {
+		if fes[i].Name != e.Name || fes[i].Type != e.Type || !fes[i].Equal(e.Pos) || fes[i].Source != "test.dat" {
+			t.Errorf("[%d] expected %s %s %s, saw %s", i, e.Name, e.Type, e.Pos, fes[i])
+		}
+	}
+
+	// The box leaves out IXYZ; the second RW28L clashes with the first.
+	box := Latlong{37.1,-122.5}.BoxTo(Latlong{37.8,-121.5})
+	fr,conflicts := NewFixRegistryFromEntries(fes, box, 0.1)
+	if fr.Len() != 5 { t.Errorf("registry: expected 5 entries, saw %v", fr.Names()) }
+	if _,exists := fr.Lookup("IXYZ"); exists { t.Errorf("registry: IXYZ is outside the box") }
+	if len(conflicts) != 1 || conflicts[0].Name != "RW28L" { t.Errorf("registry: saw conflicts %v", conflicts) }
+
+	// Zero is a valid position
+	zero := fixedWidth(map[int]string{1:"SEURDB", 14:"GW", 22:"1", 33:"N51280000E000000000"})
+	if fes,err := ParseARINC424(strings.NewReader(zero), "test.dat"); err != nil || len(fes) != 1 ||
+		fes[0].Long != 0 || fes[0].Lat != 51+28.0/60 {
+		t.Errorf("on the prime meridian, saw %v, %v", fes, err)
+	}
+
+	// An entry with no name is reported, not dropped
+	_,conflicts = NewFixRegistryFromEntries([]FixEntry{{NamedLatlong:NamedLatlong{"", Latlong{37,-122}}}}, LatlongBox{}, 0.1)
+	if len(conflicts) != 1 { t.Errorf("registry: expected a conflict for an entry with no name, saw %v", conflicts) }
+
+	bad := fixedWidth(map[int]string{1:"SUSAEAENRT", 14:"BADDY", 22:"0", 33:"X37103568W122002995"})
+	if _,err := ParseARINC424(strings.NewReader(bad), "test.dat"); err == nil {
+		t.Errorf("expected an error for a bad latitude")
+	}
+}
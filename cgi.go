@@ -31,12 +31,14 @@ func FormValueLatlong(r *http.Request, stem string) Latlong {
 	return Latlong{lat,long}
 }
 
-func FormValueNamedLatlong(r *http.Request, names map[string]Latlong, stem string) NamedLatlong {
+// If a name is given, it is looked up (e.g. in a FixRegistry); else the lat/long fields are used.
+func FormValueNamedLatlong(r *http.Request, fixes FixLookup, stem string) NamedLatlong {
 	if name := strings.ToUpper(r.FormValue(stem+"_name")); name != "" {
-		if _,exists := names[name]; !exists {
+		nl,exists := fixes.Lookup(name)
+		if !exists {
 			return NamedLatlong{Name:"[UNKNOWN]"} //, fmt.Errorf("Waypoint '%s' not known", wp)
 		}
-		return NamedLatlong{name, names[name]}
+		return nl
 	}
 
 	return NamedLatlong{"", FormValueLatlong(r,stem)}
@@ -103,3 +105,69 @@ func FormValueLatlongLine(r *http.Request, stem string) LatlongLine {
 	e := FormValueLatlong(r, stem+"_endpos")
 	return s.LineTo(e)
 }
+
+// you'll want widget.AddPrefixedValues(v, cr.Values(), "mystem")
+func (cr CircleRestriction)Values() url.Values {
+	v := url.Values{}
+	widget.AddPrefixedValues(v, cr.NamedLatlong.Values(), "center")
+	v.Set("radiuskm", fmt.Sprintf("%.2f", cr.RadiusKM))
+	if cr.AltitudeMin > 0 { v.Set("altmin", fmt.Sprintf("%d", cr.AltitudeMin)) }
+	if cr.AltitudeMax > 0 { v.Set("altmax", fmt.Sprintf("%d", cr.AltitudeMax)) }
+	if cr.IsExcluding { v.Set("excluding", "1") }
+	return v
+}
+func (cr CircleRestriction)ToCGIArgs(stem string) string {
+	v := url.Values{}
+	widget.AddPrefixedValues(v, cr.Values(), stem)
+	return v.Encode()
+}
+
+// If the center is given by name, it is looked up in fixes
+func FormValueCircleRestriction(r *http.Request, fixes FixLookup, stem string) CircleRestriction {
+	return CircleRestriction{
+		NamedLatlong: FormValueNamedLatlong(r, fixes, stem+"_center"),
+		RadiusKM: formValueFloat64EatErrs(r, stem+"_radiuskm"),
+		AltitudeMin: formValueInt64EatErrs(r, stem+"_altmin"),
+		AltitudeMax: formValueInt64EatErrs(r, stem+"_altmax"),
+		IsExcluding: r.FormValue(stem+"_excluding") != "",
+	}
+}
+
+// The polygon's points are encoded as repeated "points=lat,long" values, in order.
+func (pr PolygonRestriction)Values() url.Values {
+	v := url.Values{}
+	if pr.Name != "" { v.Set("name", pr.Name) }
+	if pr.Polygon != nil {
+		for _,pt := range pr.Polygon.GetPoints() {
+			v.Add("points", fmt.Sprintf("%.5f,%.5f", pt.Lat, pt.Long))
+		}
+	}
+	if pr.AltitudeMin > 0 { v.Set("altmin", fmt.Sprintf("%d", pr.AltitudeMin)) }
+	if pr.AltitudeMax > 0 { v.Set("altmax", fmt.Sprintf("%d", pr.AltitudeMax)) }
+	if pr.IsExcluding { v.Set("excluding", "1") }
+	return v
+}
+func (pr PolygonRestriction)ToCGIArgs(stem string) string {
+	v := url.Values{}
+	widget.AddPrefixedValues(v, pr.Values(), stem)
+	return v.Encode()
+}
+
+// Points that fail to parse are skipped.
+func FormValuePolygonRestriction(r *http.Request, stem string) PolygonRestriction {
+	r.ParseForm()
+	poly := NewPolygon()
+	for _,str := range r.Form[stem+"_points"] {
+		if pos := NewLatlong(str); !pos.IsNil() {
+			poly.AddPoint(pos)
+		}
+	}
+
+	return PolygonRestriction{
+		Polygon: poly,
+		Name: r.FormValue(stem+"_name"),
+		AltitudeMin: formValueInt64EatErrs(r, stem+"_altmin"),
+		AltitudeMax: formValueInt64EatErrs(r, stem+"_altmax"),
+		IsExcluding: r.FormValue(stem+"_excluding") != "",
+	}
+}
@@ -1,6 +1,12 @@
 package geo
 
-import "fmt"
+import(
+	"fmt"
+	"math"
+)
+
+// How many sides to use when a circle has to become a polygon
+const kCirclePolygonSides = 36
 
 type LatlongCircle struct {
 	Latlong
@@ -15,27 +21,187 @@ func (pos Latlong)Circle(radius float64) LatlongCircle {
 	return LatlongCircle{pos, radius}
 }
 
-// Implement GeoRestricter interface
-func (c LatlongCircle)LookForExit() bool { return true }
+// {{{ c.toPlane, c.fromPlane
+
+// The intersection maths is done on a flat plane, centred on the circle, with units of KM. This
+// is fine for the short distances involved in airspace and restrictions.
+const kKMPerDegree = earthRadiusKM * (math.Pi / 180.0)
+
+func (c LatlongCircle)toPlane(pos Latlong) (x,y float64) {
+	scale := math.Cos(c.Lat * (math.Pi / 180.0))
+	return (pos.Long - c.Long) * scale * kKMPerDegree, (pos.Lat - c.Lat) * kKMPerDegree
+}
+
+func (c LatlongCircle)fromPlane(x,y float64) Latlong {
+	scale := math.Cos(c.Lat * (math.Pi / 180.0))
+	return Latlong{
+		Lat: c.Lat + y/kKMPerDegree,
+		Long: c.Long + x/(scale*kKMPerDegree),
+	}
+}
+
+// }}}
+
+// {{{ c.IntersectsLine, c.IntersectionPoints
+
 func (c LatlongCircle)IntersectsLine(l LatlongLine) bool {
-	// http://math.stackexchange.com/questions/228841/how-do-i-calculate-the-intersections-of-a-straight-line-and-a-circle
-	return false // Implement me
+	_,intersects := c.IntersectionPoints(l)
+	return intersects
 }
-func (c LatlongCircle)IntersectsAltitude(alt int64) bool {
-	return false // Implement me, too
+
+// IntersectionPoints returns the points where the bounded line crosses the edge of the circle, in
+// the order they are met when travelling from l.From to l.To; so if there are two, the first is
+// the entry point and the second the exit point. A tangent line yields a single point.
+// http://math.stackexchange.com/questions/228841/how-do-i-calculate-the-intersections-of-a-straight-line-and-a-circle
+func (c LatlongCircle)IntersectionPoints(l LatlongLine) ([]Latlong, bool) {
+	ret := []Latlong{}
+
+	x1,y1 := c.toPlane(l.From)
+	x2,y2 := c.toPlane(l.To)
+	dx,dy := x2-x1, y2-y1
+
+	// Solve |From + t.(To-From)| == r, as the quadratic a.t^2 + b.t + cc == 0
+	a := dx*dx + dy*dy
+	b := 2 * (x1*dx + y1*dy)
+	cc := x1*x1 + y1*y1 - c.RadiusKM*c.RadiusKM
+
+	if a == 0 { return ret, false } // Degenerate line
+
+	disc := b*b - 4*a*cc
+	if disc < 0 { return ret, false } // Line misses the circle entirely
+
+	ts := []float64{ (-b - math.Sqrt(disc)) / (2*a) }
+	if disc > 0 { ts = append(ts, (-b + math.Sqrt(disc)) / (2*a)) }
+
+	for _,t := range ts {
+		if t < 0.0 || t > 1.0 { continue } // Intersection lies beyond the bounds of the line
+		ret = append(ret, c.fromPlane(x1 + t*dx, y1 + t*dy))
+	}
+
+	return ret, (len(ret)>0)
 }
 
+// }}}
+// {{{ c.IntersectsCircle
 
-func (c LatlongCircle)IntersectsLineDeb(l LatlongLine) (bool,string) {return c.IntersectsLine(l),""}
+// IntersectsCircle returns the points where the edges of the two circles cross. If one circle
+// lies entirely inside the other, or they are disjoint, there are no such points.
+func (c LatlongCircle)IntersectsCircle(c2 LatlongCircle) ([]Latlong, bool) {
+	ret := []Latlong{}
 
-// Implement Region interface (defunct ?)
-func (c LatlongCircle)ContainsPoint(pos Latlong) bool {
-	return (c.DistKM(pos) < c.RadiusKM)
+	x2,y2 := c.toPlane(c2.Latlong)
+	d := math.Sqrt(x2*x2 + y2*y2)
+	r1,r2 := c.RadiusKM, c2.RadiusKM
+
+	if d == 0 || d > r1+r2 || d < math.Abs(r1-r2) { return ret, false }
+
+	// a is the distance from our center to the chord joining the intersection points; h is
+	// half the length of that chord.
+	a := (r1*r1 - r2*r2 + d*d) / (2*d)
+	h := math.Sqrt(math.Max(r1*r1 - a*a, 0))
+
+	mx,my := a*x2/d, a*y2/d // Midpoint of the chord
+	ret = append(ret, c.fromPlane(mx + h*y2/d, my - h*x2/d))
+	if h > 0 {
+		ret = append(ret, c.fromPlane(mx - h*y2/d, my + h*x2/d))
+	}
+
+	return ret, true
+}
+
+// }}}
+// {{{ c.OverlapsLine, c.OverlapsBox, c.OverlapsPolygon
+
+func (c LatlongCircle)OverlapsLine(l LatlongLine) OverlapOutcome {
+	return lineOverlap(l, c.Contains, c.IntersectsLine)
 }
+
+// r2 is the box. Straddling boxes are reported as OverlapStraddles, as there is no ordering.
+func (c LatlongCircle)OverlapsBox(box LatlongBox) OverlapOutcome {
+	dist := box.SignedDistanceKM(c.Latlong)
+	if dist > c.RadiusKM { return Disjoint }
+	if dist <= -1 * c.RadiusKM { return OverlapR2Contains }
+
+	if c.Contains(box.SW) && c.Contains(box.NE) && c.Contains(box.NW()) && c.Contains(box.SE()) {
+		return OverlapR2IsContained
+	}
+
+	return OverlapStraddles
+}
+
+// r2 is the polygon. As for OverlapsBox, straddles are reported as OverlapStraddles.
+func (c LatlongCircle)OverlapsPolygon(poly *Polygon) OverlapOutcome {
+	dist := poly.SignedDistanceKM(c.Latlong)
+	if dist > c.RadiusKM { return Disjoint }
+	if dist <= -1 * c.RadiusKM { return OverlapR2Contains }
+
+	for _,pt := range poly.GetPoints() {
+		if !c.Contains(pt) { return OverlapStraddles }
+	}
+
+	return OverlapR2IsContained
+}
+
+// }}}
+// {{{ c.NearestBoundaryPoint, c.SignedDistanceKM
+
+func (c LatlongCircle)NearestBoundaryPoint(pos Latlong) Latlong {
+	x,y := c.toPlane(pos)
+	d := math.Sqrt(x*x + y*y)
+	if d == 0 { return c.MoveKM(0, c.RadiusKM) } // Every point is nearest; pick north
+	return c.fromPlane(x*c.RadiusKM/d, y*c.RadiusKM/d)
+}
+
+// SignedDistanceKM is the distance from pos to the edge of the circle; negative if inside.
+func (c LatlongCircle)SignedDistanceKM(pos Latlong) float64 {
+	return c.DistKM(pos) - c.RadiusKM
+}
+
+// }}}
+// {{{ c.ToPolygon
+
+// ToPolygon approximates the circle with a regular polygon of nSides, whose vertices lie on the
+// circle, in clockwise order starting at due north.
+func (c LatlongCircle)ToPolygon(nSides int) *Polygon {
+	poly := NewPolygon()
+	if nSides < 3 { nSides = 3 }
+	for i:=0; i<nSides; i++ {
+		poly.AddPoint(c.MoveKM(float64(i) * 360.0 / float64(nSides), c.RadiusKM))
+	}
+	return poly
+}
+
+// }}}
+
+// Implement GeoRestricter interface
+func (c LatlongCircle)LookForExit() bool { return true }
+// A circle has no altitude limits
+func (c LatlongCircle)IntersectsAltitude(alt int64) bool { return true }
+
+func (c LatlongCircle)IntersectsLineDeb(l LatlongLine) (bool,string) {
+	return c.IntersectsLine(l),""
+}
+
+// Implement Region interface (defunct ?)
+func (c LatlongCircle)ContainsPoint(pos Latlong) bool { return c.Contains(pos) }
 func (c LatlongCircle)IntersectsBox(b2 LatlongBox) bool {
-	return false // Implement me
+	return ! c.OverlapsBox(b2).IsDisjoint()
+}
+
+// Implement most of the Intersector interface
+func (c LatlongCircle)BoundingBox() LatlongBox {
+	return LatlongBox{
+		SW: Latlong{Lat:c.MoveKM(180, c.RadiusKM).Lat, Long:c.MoveKM(270, c.RadiusKM).Long},
+		NE: Latlong{Lat:c.MoveKM(  0, c.RadiusKM).Lat, Long:c.MoveKM( 90, c.RadiusKM).Long},
+	}
+}
+func (c LatlongCircle)CanContain() bool { return true }
+func (c LatlongCircle)Contains(pos Latlong) bool {
+	return (c.DistKM(pos) < c.RadiusKM)
 }
+func (c LatlongCircle)OverlapsAltitude(alt int64) OverlapOutcome { return OverlapR2IsContained }
 
-// Implement MapRenderer interface
+// Implement MapRenderer interface. A circle renders as itself; renderers that can only draw
+// lines can use ToPolygon.
 func (c LatlongCircle)ToLines() []LatlongLine { return []LatlongLine{} }
 func (c LatlongCircle)ToCircles() []LatlongCircle { return []LatlongCircle{c} }
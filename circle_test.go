@@ -0,0 +1,144 @@
+package geo
+// go test -v github.com/skypies/geo
+
+import(
+	"math"
+	"testing"
+)
+
+func TestCircleIntersectsLine(t *testing.T) {
+	c := Latlong{37,-122}.Circle(10)
+	west,east := c.MoveKM(270, 20), c.MoveKM(90, 20)
+	above := c.MoveKM(0, 10.1)
+
+	tests := []struct{
+		A,B Latlong
+		N   int
+	}{
+		{west,                east,                2}, // straight through the middle
+		{c.Latlong,           east,                1}, // from the center, out
+		{west,                c.Latlong,           1}, // from outside, into the center
+		{west,                west.MoveKM(0, 20),  0}, // misses entirely
+		{c.MoveKM(0,1),       c.MoveKM(180,1),     0}, // entirely inside
+		{above.MoveKM(270,5), above.MoveKM(90,5),  0}, // just misses
+	}
+
+	for i,test := range tests {
+		pts,_ := c.IntersectionPoints(test.A.LineTo(test.B))
+		if len(pts) != test.N {
+			t.Errorf("IntersectionPoints[%d]: expected %d, saw %d: %v\n", i, test.N, len(pts), pts)
+		}
+		if c.IntersectsLine(test.A.LineTo(test.B)) != (test.N > 0) {
+			t.Errorf("IntersectsLine[%d]: expected %v\n", i, test.N > 0)
+		}
+		for _,pt := range pts {
+			if dist := c.DistKM(pt); math.Abs(dist - c.RadiusKM) > 0.05 {
+				t.Errorf("IntersectionPoints[%d]: %s not on the circle (dist=%.3f)\n", i, pt, dist)
+			}
+		}
+	}
+
+	// Entry should come before exit
+	pts,_ := c.IntersectionPoints(west.LineTo(east))
+	if pts[0].Long > pts[1].Long {
+		t.Errorf("IntersectionPoints: entry %s should be west of exit %s\n", pts[0], pts[1])
+	}
+}
+
+func TestCircleIntersectsCircle(t *testing.T) {
+	c := Latlong{37,-122}.Circle(10)
+
+	tests := []struct{
+		C2 LatlongCircle
+		N  int
+	}{
+		{c.MoveKM( 90, 10).Circle(10), 2},
+		{c.MoveKM( 90, 21).Circle(10), 0}, // just apart
+		{c.MoveKM( 90, 30).Circle(10), 0},
+		{c.MoveKM( 90,  2).Circle( 2), 0}, // contained
+		{c.Latlong.Circle(10),         0}, // identical
+	}
+
+	for i,test := range tests {
+		pts,_ := c.IntersectsCircle(test.C2)
+		if len(pts) != test.N {
+			t.Errorf("IntersectsCircle[%d]: expected %d, saw %d: %v\n", i, test.N, len(pts), pts)
+		}
+		for _,pt := range pts {
+			d1,d2 := c.DistKM(pt), test.C2.DistKM(pt)
+			if math.Abs(d1 - c.RadiusKM) > 0.05 || math.Abs(d2 - test.C2.RadiusKM) > 0.05 {
+				t.Errorf("IntersectsCircle[%d]: %s not on both circles (%.3f,%.3f)\n", i, pt, d1, d2)
+			}
+		}
+	}
+}
+
+func TestCircleOverlaps(t *testing.T) {
+	c := Latlong{37,-122}.Circle(10)
+
+	lineTests := []struct{
+		Expected OverlapOutcome
+		A,B      Latlong
+	}{
+		{Disjoint,                c.MoveKM(0,20),  c.MoveKM(90,20)},
+		{OverlapR2IsContained,    c.MoveKM(0,5),   c.MoveKM(90,5)},
+		{OverlapR2StraddlesStart, c.MoveKM(0,20),  c.MoveKM(0,5)},
+		{OverlapR2StraddlesEnd,   c.MoveKM(0,5),   c.MoveKM(0,20)},
+		{OverlapR2Contains,       c.MoveKM(0,20),  c.MoveKM(180,20)},
+	}
+	for i,test := range lineTests {
+		if actual := c.OverlapsLine(test.A.LineTo(test.B)); actual != test.Expected {
+			t.Errorf("OverlapsLine[%d]: expected %v, saw %v\n", i, test.Expected, actual)
+		}
+	}
+
+	boxTests := []struct{
+		Expected OverlapOutcome
+		Box      LatlongBox
+	}{
+		{Disjoint,             c.MoveKM(90,30).Box(5,5)},
+		{OverlapR2IsContained, c.Box(5,5)},
+		{OverlapR2Contains,    c.Box(50,50)},
+		{OverlapStraddles,     c.MoveKM(90,10).Box(5,5)},
+	}
+	for i,test := range boxTests {
+		if actual := c.OverlapsBox(test.Box); actual != test.Expected {
+			t.Errorf("OverlapsBox[%d]: expected %v, saw %v\n", i, test.Expected, actual)
+		}
+	}
+
+	polyTests := []struct{
+		Expected OverlapOutcome
+		Poly     *Polygon
+	}{
+		{Disjoint,             c.MoveKM(90,30).Circle(5).ToPolygon(6)},
+		{OverlapR2IsContained, c.Latlong.Circle(5).ToPolygon(6)},
+		{OverlapR2Contains,    c.Latlong.Circle(50).ToPolygon(6)},
+		{OverlapStraddles,     c.MoveKM(90,10).Circle(5).ToPolygon(6)},
+	}
+	for i,test := range polyTests {
+		if actual := c.OverlapsPolygon(test.Poly); actual != test.Expected {
+			t.Errorf("OverlapsPolygon[%d]: expected %v, saw %v\n", i, test.Expected, actual)
+		}
+	}
+}
+
+func TestCircleToPolygon(t *testing.T) {
+	c := Latlong{37,-122}.Circle(10)
+	poly := c.ToPolygon(12)
+
+	if n := len(poly.GetPoints()); n != 12 {
+		t.Errorf("ToPolygon: expected 12 points, saw %d\n", n)
+	}
+	for _,pt := range poly.GetPoints() {
+		if dist := c.DistKM(pt); math.Abs(dist - c.RadiusKM) > 0.001 {
+			t.Errorf("ToPolygon: vertex %s not on circle (dist=%.3f)\n", pt, dist)
+		}
+	}
+	if len(c.ToLines()) != 0 || len(c.ToCircles()) != 1 {
+		t.Errorf("rendering: expected just the circle, saw %d lines\n", len(c.ToLines()))
+	}
+	if !poly.Contains(c.Latlong) {
+		t.Errorf("ToPolygon: polygon does not contain the center\n")
+	}
+}
@@ -1,6 +1,12 @@
 package geo
 
-import "fmt"
+import(
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/skypies/geo/altitude"
+)
 
 type Cylinder struct {
 	EndDistanceNM int  // Nautical Miles. Start distance is end of inner cylinder (or origin)
@@ -8,51 +14,118 @@ type Cylinder struct {
 	Ceil       int     // In hundreds of feet
 }
 
-// Each sector is a pie wedge, with consistent floor/ceil cylinders
+// Each sector is a pie wedge, with consistent floor/ceil cylinders. The wedge runs clockwise
+// from StartBearing to EndBearing, and may wrap through north (e.g. 300 to 30, or 300 to 390).
 type ClassBSector struct {
 	StartBearing   int  // Magnetic bearing (-13.68 to get to magnetic @ SFO)
 	EndBearing     int
 	Steps        []Cylinder // Ordered by asc DistanceNM
 }
 
+func (s ClassBSector)ContainsBearing(bearing float64) bool {
+	return BearingInRange(bearing, float64(s.StartBearing), float64(s.EndBearing))
+}
+
 type ClassBMap struct {
-	Sectors  []ClassBSector  // Must be ordered by asc StartBearing, and support wraparound
-	Center   Latlong
-	Name     string
+	Sectors      []ClassBSector  // Must be ordered by asc StartBearing, and support wraparound
+	Center       Latlong
+	Name         string
+	Declination  float64 // Magnetic declination (east is positive); sectors use magnetic bearings
+}
+
+// NewClassBMap returns a map, if the sectors pass validation.
+func NewClassBMap(name string, center Latlong, declination float64, sectors []ClassBSector) (ClassBMap, error) {
+	m := ClassBMap{
+		Sectors: sectors,
+		Center: center,
+		Name: name,
+		Declination: declination,
+	}
+	return m, m.Validate()
+}
+
+func normalizeBearing(b int) int { return ((b % 360) + 360) % 360 }
+
+// Validate checks that the sectors are in order, and go all the way round the circle without
+// any gaps or overlaps, and that the cylinders within each sector are in order.
+func (m ClassBMap)Validate() error {
+	if len(m.Sectors) == 0 {
+		return fmt.Errorf("ClassBMap %s: no sectors", m.Name)
+	}
+
+	for i,sector := range m.Sectors {
+		if len(sector.Steps) == 0 {
+			return fmt.Errorf("ClassBMap %s: sector %d has no steps", m.Name, i)
+		}
+		if len(m.Sectors) > 1 && normalizeBearing(sector.StartBearing) == normalizeBearing(sector.EndBearing) {
+			return fmt.Errorf("ClassBMap %s: sector %d is a full circle, but there are others",
+				m.Name, i)
+		}
+		for j,cyl := range sector.Steps {
+			if cyl.Floor >= cyl.Ceil {
+				return fmt.Errorf("ClassBMap %s: sector %d, step %d: floor %d not below ceil %d",
+					m.Name, i, j, cyl.Floor, cyl.Ceil)
+			}
+			if j > 0 && cyl.EndDistanceNM <= sector.Steps[j-1].EndDistanceNM {
+				return fmt.Errorf("ClassBMap %s: sector %d, step %d: distances not ascending",
+					m.Name, i, j)
+			}
+		}
+
+		if i == 0 { continue }
+		prev := m.Sectors[i-1]
+		if sector.StartBearing <= prev.StartBearing {
+			return fmt.Errorf("ClassBMap %s: sector %d: StartBearing %d not after previous (%d)",
+				m.Name, i, sector.StartBearing, prev.StartBearing)
+		}
+		if normalizeBearing(sector.StartBearing) != normalizeBearing(prev.EndBearing) {
+			return fmt.Errorf("ClassBMap %s: sector %d: gap or overlap, previous ends at %d, this starts at %d",
+				m.Name, i, prev.EndBearing, sector.StartBearing)
+		}
+	}
+
+	// The last sector must wrap round to meet the first
+	first,last := m.Sectors[0], m.Sectors[len(m.Sectors)-1]
+	if normalizeBearing(last.EndBearing) != normalizeBearing(first.StartBearing) {
+		return fmt.Errorf("ClassBMap %s: gap or overlap, last sector ends at %d, first starts at %d",
+			m.Name, last.EndBearing, first.StartBearing)
+	}
+
+	return nil
+}
+
+// Radial is the magnetic bearing from the center of the map out to pos.
+func (m ClassBMap)Radial(pos Latlong) float64 {
+	return math.Mod(m.Center.BearingTowards(pos) - m.Declination + 360.0, 360.0)
 }
 
 // Walk treads a circle around the map until we find the sector that matches our bearing, and
-// then walks out from the middle until we find the zone of the sector we lie within.
-func (m ClassBMap) Walk(distNM, bearing float64) (floor,ceil int, inRange bool) {
-	inRange = false
+// then walks out from the middle until we find the zone of the sector we lie within. An error
+// is returned if no sector covers the bearing (Validate would have caught this.)
+func (m ClassBMap) Walk(distNM, bearing float64) (floor,ceil int, inRange bool, err error) {
 	// Walk the sectors until we find the first one which contains our bearing
-	// TODO: funky wraparound thing
 	for _,sector := range m.Sectors {
-		if bearing < float64(sector.EndBearing) {
-			// Now, walk the Cylinders and find first one that contains our distance
-			for _,cyl := range sector.Steps {
-				if distNM < float64(cyl.EndDistanceNM) {
-					floor, ceil, inRange = cyl.Floor, cyl.Ceil, true
-					return // We found our class B limits !
-				}
+		if !sector.ContainsBearing(bearing) { continue }
+
+		// Now, walk the Cylinders and find first one that contains our distance
+		for _,cyl := range sector.Steps {
+			if distNM < float64(cyl.EndDistanceNM) {
+				return cyl.Floor, cyl.Ceil, true, nil // We found our class B limits !
 			}
 		}
-		return // We are past the outer limits of this sector's cylinders; not in range
+		return 0, 0, false, nil // We are past the outer limits of this sector's cylinders
 	}
 
-	panic(fmt.Sprintf("Bad ClassBMap, we fell off the end, given bearing=%f", bearing))
-	return
+	return 0, 0, false, fmt.Errorf("ClassBMap %s: no sector for bearing %.1f", m.Name, bearing)
 }
 
 // ClassBRange works out if a position is within range of the given map; and if so, what the
 // altitude limits are at that position.
-func (m ClassBMap)ClassBRange(pos Latlong) (floor,ceil float64, inRange bool) {
-	floor,ceil,inRange = 0.0, 0.0, false
+func (m ClassBMap)ClassBRange(pos Latlong) (floor,ceil float64, inRange bool, err error) {
 	distNM := pos.DistNM(m.Center)
-	bearing := pos.BearingTowards(m.Center)
 
 	var f,c int
-	f,c,inRange = m.Walk(distNM, bearing)
+	f,c,inRange,err = m.Walk(distNM, m.Radial(pos))
 	if inRange {
 		floor = float64(f) * 100.0
 		ceil = float64(c) * 100.0
@@ -66,12 +139,15 @@ type TPClassBAnalysis struct {
 	WithinRange         bool    // If we're not within range, the rest has no meaning.
 	VerticalDisposition int     // <0 below; =0 within; >0 above
 	BelowBy             float64 // If below, by how many feet
-	Reasoning           string  // Explanation of stuff
+	Reasoning           Reasoning // Explanation of stuff; render with String() or HTML()
 
 	// Handy data to have around later
 	I                   int     // Index into the track for the point we've analyzed
 	InchesHg            float64 // The pressure correction applied
 	IndicatedAltitude   float64 // The pressure corrected altitude
+	PressureAltitude    float64 // The uncorrected altitude
+	AltitudeCorrection  float64 // IndicatedAltitude - PressureAltitude, in feet
+	AltimeterSource     string  // Where InchesHg came from; empty if no correction was made
 	Floor,Ceil          float64 // The Class B space the point was in (0 if not in space)
 	DistNM              float64 // Seeing as we've calculated it :)
 
@@ -83,41 +159,79 @@ func (a TPClassBAnalysis)IsViolation() bool {
 	return a.VerticalDisposition < 0
 }
 
-func (m ClassBMap)ClassBPointAnalysis(pos Latlong, speed float64, alt,tol float64, o *TPClassBAnalysis) {
+func (m ClassBMap)ClassBPointAnalysis(pos Latlong, speed float64, alt,tol float64, o *TPClassBAnalysis) error {
+	o.Reasoning = Reasoning{}
+	return m.classBPointAnalysis(pos, speed, alt, tol, o)
+}
+
+// classBPointAnalysis appends its reasoning to whatever is already in o.
+func (m ClassBMap)classBPointAnalysis(pos Latlong, speed float64, alt,tol float64, o *TPClassBAnalysis) error {
 	distNM := pos.DistNM(m.Center)
-	bearing := pos.BearingTowards(m.Center)
+	bearing := m.Radial(pos)
 	o.DistNM = distNM
 
-	o.Reasoning = fmt.Sprintf("** ClassB analysis: aircraft at %s, %.0f kt, %.0f feet\n",
-		pos,speed,alt)
-	o.Reasoning += fmt.Sprintf("* Distance to %s in NM: %.1f; bearing towards %s: %.1f\n",
-		m.Name, distNM, m.Name, bearing)
+	o.Reasoning.TextFact("aircraft position", pos.String())
+	o.Reasoning.Fact("groundspeed", speed, "kt")
+	o.Reasoning.Fact("altitude", alt, "ft")
+	o.Reasoning.Fact("distance to "+m.Name, distNM, "NM")
+	o.Reasoning.Fact("radial from "+m.Name, bearing, "deg")
 
-	o.Floor,o.Ceil,o.WithinRange = m.ClassBRange(pos)
+	var err error
+	if o.Floor,o.Ceil,o.WithinRange,err = m.ClassBRange(pos); err != nil {
+		return err
+	}
 	
 	if !o.WithinRange {
-		o.Reasoning += "* not in range; too far away from "+m.Name+"\n"
-		return
+		o.Reasoning.Note("not in range; too far away from "+m.Name)
+		return nil
 	}
 
 	limitStr := fmt.Sprintf("%d/%d", int(o.Ceil/100.0), int(o.Floor/100.0))
-	o.Reasoning += fmt.Sprintf("* In <b>%s</b> space, at <b>%.0f</b> feet\n", limitStr, alt)
+	o.Reasoning = append(o.Reasoning, ReasoningStep{Fact:"class B space", Text:limitStr, Emphasize:true})
+	o.Reasoning.KeyFact("class B floor", o.Floor, "ft")
 	
 	if (alt > o.Ceil) {
 		o.VerticalDisposition = 1
-		o.Reasoning += "* above class B ceiling\n"
+		o.Reasoning.Note("above class B ceiling")
 		
 	} else if (alt > o.Floor-tol-1) {  // Allow <tol> feet of wriggle room
 		o.VerticalDisposition = 0
-		o.Reasoning += "* within (tolerance of) class B height range\n"
+		o.Reasoning.Fact("tolerance", tol, "ft")
+		o.Reasoning.Note("within (tolerance of) class B height range")
 		
 	} else {
 		o.VerticalDisposition = -1
-		o.Reasoning += "* below class B floor\n"
 		o.BelowBy = o.Floor - alt
+		o.Reasoning.Note("below class B floor")
+		o.Reasoning.KeyFact("below floor by", o.BelowBy, "ft")
 	}
 
-	return
+	return nil
+}
+
+// ClassBCorrectedPointAnalysis converts the pressure altitude into an indicated altitude, using
+// the altimeter setting from src for the time and place, and then judges it against the map.
+func (m ClassBMap)ClassBCorrectedPointAnalysis(pos Latlong, t time.Time, speed float64, pressureAlt,tol float64, src AltimeterSource, o *TPClassBAnalysis) error {
+	inHg,err := src.InchesHg(pos, t)
+	if err != nil {
+		return fmt.Errorf("ClassB analysis: no altimeter setting from %s: %v", src, err)
+	}
+
+	indicatedAlt := altitude.PressureAltitudeToIndicatedAltitude(pressureAlt, inHg)
+
+	o.InchesHg = inHg
+	o.PressureAltitude = pressureAlt
+	o.IndicatedAltitude = indicatedAlt
+	o.AltitudeCorrection = indicatedAlt - pressureAlt
+	o.AltimeterSource = src.String()
+
+	o.Reasoning = Reasoning{}
+	o.Reasoning.Fact("pressure altitude", pressureAlt, "ft")
+	o.Reasoning.TextFact("altimeter source", o.AltimeterSource)
+	o.Reasoning.Fact("altimeter setting", inHg, "inHg")
+	o.Reasoning.Fact("pressure correction", o.AltitudeCorrection, "ft")
+
+	return m.classBPointAnalysis(pos, speed, indicatedAlt, tol, o)
 }
 
 // {{{ -------------------------={ E N D }=----------------------------------
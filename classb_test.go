@@ -0,0 +1,228 @@
+package geo
+// go test -v github.com/skypies/geo
+
+import(
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Three sectors, the last of which wraps through north
+var testWrappingClassBMap = ClassBMap{
+	Name: "TEST",
+	Center: Latlong{37,-122},
+	Sectors: []ClassBSector{
+		{ 30, 150, []Cylinder{{5,0,100}, {10,20,100}}},
+		{150, 270, []Cylinder{{5,0,100}}},
+		{270, 390, []Cylinder{{5,0,100}, {10,20,100}, {15,40,100}}},
+	},
+}
+
+func TestClassBWalk(t *testing.T) {
+	m := testWrappingClassBMap
+	if err := m.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	tests := []struct{
+		DistNM, Bearing float64
+		InRange         bool
+		Floor           int
+	}{
+		{ 3,  90, true,   0},
+		{ 7,  90, true,  20},
+		{12,  90, false,  0}, // beyond the east sector
+		{ 7, 200, false,  0}, // beyond the south sector
+		{12, 300, true,  40},
+		{12,   0, true,  40}, // wrapped round through north
+		{12,  20, true,  40},
+		{12,  40, false,  0},
+	}
+
+	for i,test := range tests {
+		floor,_,inRange,err := m.Walk(test.DistNM, test.Bearing)
+		if err != nil {
+			t.Errorf("[t%d] err: %v", i, err)
+		} else if inRange != test.InRange || floor != test.Floor {
+			t.Errorf("[t%d] %.0fNM@%.0f: expected (%v,%d), got (%v,%d)", i, test.DistNM,
+				test.Bearing, test.InRange, test.Floor, inRange, floor)
+		}
+	}
+
+	// Bearings are measured out from the center
+	pos := m.Center.MoveNM(0,12)
+	if _,_,inRange,_ := m.ClassBRange(pos); !inRange {
+		t.Errorf("ClassBRange: %s, 12NM north, should be in range", pos)
+	}
+}
+
+func TestClassBValidate(t *testing.T) {
+	steps := []Cylinder{{5,0,100}}
+
+	tests := []struct{
+		Sectors []ClassBSector
+		IsValid bool
+	}{
+		{[]ClassBSector{{0, 360, steps}}, true},
+		{[]ClassBSector{{0, 180, steps}, {180, 360, steps}}, true},
+		{[]ClassBSector{{90, 270, steps}, {270, 90, steps}}, true},
+		{[]ClassBSector{}, false},
+		{[]ClassBSector{{0, 180, steps}, {190, 360, steps}}, false},      // gap
+		{[]ClassBSector{{0, 180, steps}, {180, 350, steps}}, false},      // gap at wraparound
+		{[]ClassBSector{{180, 360, steps}, {0, 180, steps}}, false},      // out of order
+		{[]ClassBSector{{0, 360, []Cylinder{}}}, false},                  // no steps
+		{[]ClassBSector{{0, 360, []Cylinder{{10,0,100}, {5,20,100}}}}, false}, // steps out of order
+		{[]ClassBSector{{0, 360, []Cylinder{{10,100,50}}}}, false},       // upside down
+	}
+
+	for i,test := range tests {
+		_,err := NewClassBMap("TEST", Latlong{37,-122}, 0, test.Sectors)
+		if (err == nil) != test.IsValid {
+			t.Errorf("[t%d] expected valid=%v, got err=%v", i, test.IsValid, err)
+		}
+	}
+
+	// An invalid map shouldn't panic when walked off the end
+	m := ClassBMap{Name: "GAPPY", Sectors: []ClassBSector{{0, 180, steps}}}
+	if _,_,_,err := m.Walk(1, 270); err == nil {
+		t.Errorf("Walk: expected an error for a bearing that no sector covers")
+	}
+}
+
+func TestClassBAnalyzeTrack(t *testing.T) {
+	m := testWrappingClassBMap
+	t0 := time.Date(2016, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Flying due north, out from the center
+	tps := []struct{ DistNM, Alt float64 }{
+		{ 3, 1000},  // no floor here
+		{ 6, 1500},  // below the 20 shelf ...
+		{ 7, 1200},
+		{ 8, 1800},  // ... for three points
+		{ 9, 3000},
+		{11, 3000},  // a blip below the 40 shelf
+		{12, 5000},
+		{14, 3500},  // a blip, at the end of the track
+	}
+	track := []ClassBTrackPoint{}
+	for i,tp := range tps {
+		track = append(track, ClassBTrackPoint{
+			Latlong: m.Center.MoveKM(0, NM2KM(tp.DistNM)),
+			TimestampUTC: t0.Add(time.Duration(i) * 10 * time.Second),
+			GroundSpeed: 180,
+			Altitude: tp.Alt,
+		})
+	}
+
+	a,err := m.AnalyzeTrack(track, 0, nil)
+	if err != nil { t.Fatalf("AnalyzeTrack: %v", err) }
+
+	if len(a.Points) != len(track) {
+		t.Errorf("expected %d point analyses, saw %d", len(track), len(a.Points))
+	}
+	if n := len(a.Sustained()); n != 1 {
+		t.Fatalf("expected 1 sustained violation, saw %d: %v", n, a.Violations)
+	}
+	if n := len(a.Transients()); n != 2 {
+		t.Errorf("expected 2 transient violations, saw %d: %v", n, a.Violations)
+	}
+
+	v := a.Sustained()[0]
+	if v.I != 1 || v.J != 3 || v.Duration != 20*time.Second {
+		t.Errorf("bad extent for violation %s", v)
+	}
+	if math.Abs(v.DistanceNM - 2.0) > 0.01 {
+		t.Errorf("expected 2.0NM below the floor, saw %.2f", v.DistanceNM)
+	}
+	if v.MaxBelowBy != 800 || a.MaxBelowBy() != 800 {
+		t.Errorf("expected MaxBelowBy of 800, saw %.0f", v.MaxBelowBy)
+	}
+	if len(v.Shelves) != 1 || v.Shelves[0] != "100/20" {
+		t.Errorf("expected shelves [100/20], saw %v", v.Shelves)
+	}
+
+	// Allowing a point splits the sustained violation
+	a.Points[2].AllowThisPoint = true
+	if n := len(FindClassBViolations(track, a.Points)); n != 4 {
+		t.Errorf("expected 4 violations once a point is allowed, saw %d", n)
+	}
+}
+
+func TestClassBCorrectedPointAnalysis(t *testing.T) {
+	m := testWrappingClassBMap
+	t0 := time.Date(2016, 6, 1, 12, 0, 0, 0, time.UTC)
+	morning := t0.Add(-2 * time.Hour)
+	pos := m.Center.MoveKM(0, NM2KM(7)) // under the 20 shelf
+	morningHigh := AltimeterFunc{"morning high", func(pos Latlong, t time.Time) (float64, error) {
+		if t.Hour() < 12 { return 30.42, nil }
+		return 29.92, nil
+	}}
+
+	tests := []struct{
+		Src          AltimeterSource
+		T            time.Time
+		PressureAlt  float64
+		IsViolation  bool
+		Correction   float64 // approx, in feet
+	}{
+		{FixedAltimeter(29.92), t0,      1900, true,     0},
+		{FixedAltimeter(30.42), t0,      1900, false,  460}, // high pressure; we're actually ~460ft higher
+		{FixedAltimeter(29.42), t0,      2100, true,  -460}, // low pressure; we're actually ~460ft lower
+		{morningHigh,           morning, 1900, false,  460},
+		{morningHigh,           t0,      1900, true,     0},
+	}
+
+	for i,test := range tests {
+		o := TPClassBAnalysis{}
+		err := m.ClassBCorrectedPointAnalysis(pos, test.T, 180, test.PressureAlt, 0, test.Src, &o)
+		if err != nil {
+			t.Fatalf("[t%d] err: %v", i, err)
+		}
+		if o.IsViolation() != test.IsViolation {
+			t.Errorf("[t%d] expected violation=%v, got %v (%.0f => %.0f)", i, test.IsViolation,
+				o.IsViolation(), o.PressureAltitude, o.IndicatedAltitude)
+		}
+		if o.AltimeterSource != test.Src.String() || o.PressureAltitude != test.PressureAlt {
+			t.Errorf("[t%d] source/pressure alt not recorded: %#v", i, o)
+		}
+		if math.Abs(o.IndicatedAltitude - o.PressureAltitude - o.AltitudeCorrection) > 0.001 {
+			t.Errorf("[t%d] correction %.1f doesn't add up", i, o.AltitudeCorrection)
+		}
+		if math.Abs(o.AltitudeCorrection - test.Correction) > 20 {
+			t.Errorf("[t%d] expected correction ~%.0f, got %.1f", i, test.Correction, o.AltitudeCorrection)
+		}
+	}
+}
+
+func TestClassBReasoning(t *testing.T) {
+	m := testWrappingClassBMap
+	o := TPClassBAnalysis{}
+	if err := m.ClassBPointAnalysis(m.Center.MoveKM(0, NM2KM(7)), 180, 1500, 0, &o); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	text := o.Reasoning.String()
+	for _,expected := range []string{"* class B space: 100/20\n", "* below floor by: 500ft\n",
+		"* distance to TEST: 7.0NM\n"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("text reasoning lacks %q:\n%s", expected, text)
+		}
+	}
+	if strings.Contains(text, "<") {
+		t.Errorf("text reasoning contains markup:\n%s", text)
+	}
+
+	if h := o.Reasoning.HTML(); !strings.Contains(h, "class B space: <b>100/20</b>") {
+		t.Errorf("HTML reasoning lacks emphasis:\n%s", h)
+	}
+
+	b,err := json.Marshal(o)
+	if err != nil { t.Fatalf("json.Marshal: %v", err) }
+	o2 := TPClassBAnalysis{}
+	if err := json.Unmarshal(b, &o2); err != nil { t.Fatalf("json.Unmarshal: %v", err) }
+	if o2.Reasoning.String() != text || o2.BelowBy != o.BelowBy || !o2.IsViolation() {
+		t.Errorf("JSON round trip failed:\n%s\n%#v", b, o2)
+	}
+}
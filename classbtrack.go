@@ -0,0 +1,120 @@
+package geo
+
+import(
+	"fmt"
+	"time"
+)
+
+// ClassBTrackPoint is what we need to know about each point of a track, to analyze it.
+type ClassBTrackPoint struct {
+	Latlong                   // embed
+	TimestampUTC time.Time
+	GroundSpeed  float64      // knots
+	Altitude     float64      // feet; a pressure altitude, if it is to be corrected
+}
+
+// ClassBViolation is a contiguous run of trackpoints that were below the ClassB floor.
+type ClassBViolation struct {
+	I,J          int           // Indices of the first and last violating points in the track
+	Start,End    time.Time
+	Duration     time.Duration
+	DistanceNM   float64       // Distance flown below the floor (from point I to point J)
+	MaxBelowBy   float64       // The deepest excursion below the floor, in feet
+	Shelves    []string        // The shelves involved, as "ceil/floor" (e.g. "100/30")
+}
+
+func (v ClassBViolation)String() string {
+	return fmt.Sprintf("[%d,%d] %s+%s, %.1fNM, max %.0fft below, shelves %v", v.I, v.J,
+		v.Start.Format("15:04:05"), v.Duration, v.DistanceNM, v.MaxBelowBy, v.Shelves)
+}
+
+// A transient violation is a single-point blip, which may well be bad data.
+func (v ClassBViolation)IsTransient() bool { return v.I == v.J }
+
+// ClassBTrackAnalysis is the output after ClassB analysis of a whole track
+type ClassBTrackAnalysis struct {
+	Points      []TPClassBAnalysis  // One per trackpoint
+	Violations  []ClassBViolation   // In track order, including transients
+}
+
+func (a ClassBTrackAnalysis)Sustained() []ClassBViolation {
+	ret := []ClassBViolation{}
+	for _,v := range a.Violations {
+		if !v.IsTransient() { ret = append(ret, v) }
+	}
+	return ret
+}
+func (a ClassBTrackAnalysis)Transients() []ClassBViolation {
+	ret := []ClassBViolation{}
+	for _,v := range a.Violations {
+		if v.IsTransient() { ret = append(ret, v) }
+	}
+	return ret
+}
+
+// MaxBelowBy is the deepest excursion below the floor over all sustained violations.
+func (a ClassBTrackAnalysis)MaxBelowBy() float64 {
+	max := 0.0
+	for _,v := range a.Sustained() {
+		if v.MaxBelowBy > max { max = v.MaxBelowBy }
+	}
+	return max
+}
+
+// AnalyzeTrack runs ClassBPointAnalysis over each point of the track, and then groups the
+// violating points into contiguous runs. If src is not nil, the altitudes are treated as
+// pressure altitudes, and corrected using the altimeter settings from src.
+func (m ClassBMap)AnalyzeTrack(track []ClassBTrackPoint, tol float64, src AltimeterSource) (ClassBTrackAnalysis, error) {
+	a := ClassBTrackAnalysis{}
+
+	for i,tp := range track {
+		o := TPClassBAnalysis{I: i}
+		var err error
+		if src == nil {
+			err = m.ClassBPointAnalysis(tp.Latlong, tp.GroundSpeed, tp.Altitude, tol, &o)
+		} else {
+			err = m.ClassBCorrectedPointAnalysis(tp.Latlong, tp.TimestampUTC, tp.GroundSpeed,
+				tp.Altitude, tol, src, &o)
+		}
+		if err != nil { return a, err }
+		a.Points = append(a.Points, o)
+	}
+
+	a.Violations = FindClassBViolations(track, a.Points)
+	return a, nil
+}
+
+// FindClassBViolations groups the violating points into contiguous runs. The point analyses
+// must line up with the track; callers can set AllowThisPoint on them before calling this.
+func FindClassBViolations(track []ClassBTrackPoint, points []TPClassBAnalysis) []ClassBViolation {
+	ret := []ClassBViolation{}
+	var curr *ClassBViolation
+
+	for i,o := range points {
+		if !o.IsViolation() {
+			if curr != nil { ret = append(ret, *curr) }
+			curr = nil
+			continue
+		}
+
+		shelf := fmt.Sprintf("%d/%d", int(o.Ceil/100.0), int(o.Floor/100.0))
+
+		if curr == nil {
+			curr = &ClassBViolation{I:i, J:i, Start:track[i].TimestampUTC, End:track[i].TimestampUTC}
+		} else {
+			curr.J = i
+			curr.End = track[i].TimestampUTC
+			curr.DistanceNM += track[i-1].DistNM(track[i].Latlong)
+		}
+		curr.Duration = curr.End.Sub(curr.Start)
+
+		if o.BelowBy > curr.MaxBelowBy { curr.MaxBelowBy = o.BelowBy }
+
+		seen := false
+		for _,s := range curr.Shelves { if s == shelf { seen = true } }
+		if !seen { curr.Shelves = append(curr.Shelves, shelf) }
+	}
+	if curr != nil { ret = append(ret, *curr) }
+
+	return ret
+}
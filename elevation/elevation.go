@@ -0,0 +1,222 @@
+// Package elevation looks up terrain heights from SRTM .hgt tiles held in a local directory, so
+// that altitudes can be reported as height above the ground.
+//
+// Tiles can be downloaded from https://dds.cr.usgs.gov/srtm/ (or elsewhere); each covers one
+// degree square, and is named after its south-west corner (e.g. N37W123.hgt). Both SRTM3
+// (1201x1201) and SRTM1 (3601x3601) tiles are supported. GeoTIFF is not.
+package elevation
+
+import(
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/skypies/geo"
+	"github.com/skypies/geo/altitude"
+)
+
+const kVoid = -32768 // SRTM's marker for missing data
+
+// Source is anything that can report the height of the ground (in feet MSL).
+type Source interface {
+	ElevationAt(pos geo.Latlong) (float64, error)
+}
+
+// SourceFunc adapts a function (e.g. a simple model of a ridge) into a Source.
+type SourceFunc func(pos geo.Latlong) (float64, error)
+func (f SourceFunc)ElevationAt(pos geo.Latlong) (float64, error) { return f(pos) }
+
+// {{{ Tile
+
+// Tile is a single .hgt file; a square grid of heights in metres, starting in the north-west
+// corner and running east then south.
+type Tile struct {
+	Lat,Long  int       // The south-west corner
+	Size      int       // Samples per side (1201 or 3601)
+	Heights []int16
+}
+
+func TileName(lat, long int) string {
+	ns,ew := 'N','E'
+	if lat < 0 { ns,lat = 'S',-lat }
+	if long < 0 { ew,long = 'W',-long }
+	return fmt.Sprintf("%c%02d%c%03d.hgt", ns, lat, ew, long)
+}
+
+func tileCorner(pos geo.Latlong) (int, int) {
+	return int(math.Floor(pos.Lat)), int(math.Floor(pos.Long))
+}
+
+// NewTile decodes the contents of a .hgt file, for the tile with the given south-west corner.
+func NewTile(lat, long int, data []byte) (*Tile, error) {
+	size := int(math.Sqrt(float64(len(data)/2)))
+	if size < 2 || size*size*2 != len(data) {
+		return nil, fmt.Errorf("tile %s: %d bytes is not a square grid", TileName(lat,long), len(data))
+	}
+	t := &Tile{Lat:lat, Long:long, Size:size, Heights:make([]int16, size*size)}
+	for i := range t.Heights {
+		t.Heights[i] = int16(binary.BigEndian.Uint16(data[i*2:]))
+	}
+	return t, nil
+}
+
+func LoadTile(filename string, lat, long int) (*Tile, error) {
+	data,err := ioutil.ReadFile(filename)
+	if err != nil { return nil, err }
+	return NewTile(lat, long, data)
+}
+
+// ElevationAt interpolates bilinearly between the four samples around pos, and returns feet.
+// Voids are skipped; if all four samples are voids, an error is returned.
+func (t *Tile)ElevationAt(pos geo.Latlong) (float64, error) {
+	n := float64(t.Size - 1)
+	row := (float64(t.Lat+1) - pos.Lat) * n
+	col := (pos.Long - float64(t.Long)) * n
+	if row < 0 || col < 0 || row > n || col > n {
+		return 0, fmt.Errorf("%s not in tile %s", pos, TileName(t.Lat,t.Long))
+	}
+
+	r,c := int(math.Min(math.Floor(row), n-1)), int(math.Min(math.Floor(col), n-1))
+	fr,fc := row - float64(r), col - float64(c)
+
+	sum,sumWeights := 0.0, 0.0
+	for _,s := range []struct{ R,C int; W float64 }{
+		{r,   c,   (1-fr)*(1-fc)},
+		{r,   c+1, (1-fr)*fc},
+		{r+1, c,   fr*(1-fc)},
+		{r+1, c+1, fr*fc},
+	} {
+		h := t.Heights[s.R*t.Size + s.C]
+		if h == kVoid { continue }
+		sum += float64(h) * s.W
+		sumWeights += s.W
+	}
+	if sumWeights == 0 { return 0, fmt.Errorf("%s: no data (void)", pos) }
+
+	return (sum / sumWeights) * altitude.FeetPerMetre, nil
+}
+
+// }}}
+// {{{ TileSource
+
+// TileSource reads tiles from Dir as they are needed, and keeps the most recently used
+// MaxTiles of them in memory. It is safe for concurrent use.
+type TileSource struct {
+	Dir          string
+	MaxTiles     int
+	MissingIsSea bool  // SRTM has no tiles for the open ocean; if true, missing tiles are at 0ft
+
+	mu           sync.Mutex
+	tiles        map[string]*Tile
+	lru          []string     // Most recently used at the end
+}
+
+func NewTileSource(dir string) *TileSource {
+	return &TileSource{Dir:dir, MaxTiles:16, tiles:map[string]*Tile{}}
+}
+
+func (ts *TileSource)String() string { return fmt.Sprintf("SRTM tiles in %s", ts.Dir) }
+
+// getTile returns nil (and no error) for a missing tile, if MissingIsSea.
+func (ts *TileSource)getTile(lat, long int) (*Tile, error) {
+	name := TileName(lat, long)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if t,exists := ts.tiles[name]; exists {
+		for i,n := range ts.lru {
+			if n == name { ts.lru = append(append(ts.lru[:i:i], ts.lru[i+1:]...), name); break }
+		}
+		return t, nil
+	}
+
+	t,err := LoadTile(filepath.Join(ts.Dir, name), lat, long)
+	if os.IsNotExist(err) && ts.MissingIsSea {
+		t,err = nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	ts.tiles[name] = t
+	ts.lru = append(ts.lru, name)
+	for len(ts.lru) > ts.MaxTiles && ts.MaxTiles > 0 {
+		delete(ts.tiles, ts.lru[0])
+		ts.lru = ts.lru[1:]
+	}
+	return t, nil
+}
+
+// ElevationAt returns the height of the ground at pos, in feet MSL. Adjacent tiles share their
+// edges, so a position on the north or east edge of a tile can use that tile if its neighbour
+// is missing.
+func (ts *TileSource)ElevationAt(pos geo.Latlong) (float64, error) {
+	lat,long := tileCorner(pos)
+	lats,longs := []int{lat}, []int{long}
+	if float64(lat) == pos.Lat { lats = append(lats, lat-1) }
+	if float64(long) == pos.Long { longs = append(longs, long-1) }
+
+	var firstErr error
+	for _,lat := range lats {
+		for _,long := range longs {
+			t,err := ts.getTile(lat, long)
+			if err != nil {
+				if firstErr == nil { firstErr = err }
+				continue
+			}
+			if t != nil { return t.ElevationAt(pos) }
+		}
+	}
+	if firstErr != nil { return 0, firstErr }
+	return 0, nil // All the candidate tiles were missing, and so are sea
+}
+
+// }}}
+
+// {{{ AGL, Profile
+
+// AGL converts an altitude (feet MSL) at pos into height above the ground.
+func AGL(src Source, pos geo.Latlong, altFeetMSL float64) (float64, error) {
+	elev,err := src.ElevationAt(pos)
+	if err != nil { return 0, err }
+	return altFeetMSL - elev, nil
+}
+
+// ProfilePoint is a sample of the terrain along a line.
+type ProfilePoint struct {
+	geo.Latlong
+	DistKM         float64 // From the start of the line
+	ElevationFeet  float64
+}
+
+// Profile samples the ground along the line, at least every stepKM, including both ends.
+func Profile(src Source, l geo.LatlongLine, stepKM float64) ([]ProfilePoint, error) {
+	if !(stepKM > 0) { return nil, fmt.Errorf("elevation: bad step %vKM", stepKM) } // Catches NaN
+	distKM := l.From.DistKM(l.To)
+	n := int(math.Ceil(distKM / stepKM))
+	if n < 1 { n = 1 }
+
+	ret := []ProfilePoint{}
+	for i:=0; i<=n; i++ {
+		ratio := float64(i) / float64(n)
+		pos := l.From.InterpolateTo(l.To, ratio)
+		elev,err := src.ElevationAt(pos)
+		if err != nil { return nil, err }
+		ret = append(ret, ProfilePoint{pos, distKM*ratio, elev})
+	}
+	return ret, nil
+}
+
+// }}}
+
+// {{{ -------------------------={ E N D }=----------------------------------
+
+// Local variables:
+// folded-file: t
+// end:
+
+// }}}
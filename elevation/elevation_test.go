@@ -0,0 +1,111 @@
+package elevation
+
+import(
+	"encoding/binary"
+	"os"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/skypies/geo"
+	"github.com/skypies/geo/altitude"
+)
+
+// writeTestTile writes an 11x11 tile for N37W123, where the ground rises by 10m for each sample
+// eastwards (so 100m per degree), with a void in the north-east corner.
+func writeTestTile(t *testing.T, dir string) {
+	size := 11
+	data := make([]byte, size*size*2)
+	for r:=0; r<size; r++ {
+		for c:=0; c<size; c++ {
+			h := int16(c * 10)
+			if r == 0 && c == size-1 { h = kVoid }
+			binary.BigEndian.PutUint16(data[(r*size+c)*2:], uint16(h))
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, TileName(37,-123)), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTileName(t *testing.T) {
+	for _,test := range []struct{ Lat,Long int; Expected string }{
+		{37, -123, "N37W123.hgt"},
+		{-5,   10, "S05E010.hgt"},
+	} {
+		if actual := TileName(test.Lat, test.Long); actual != test.Expected {
+			t.Errorf("TileName(%d,%d): expected %s, saw %s", test.Lat, test.Long, test.Expected, actual)
+		}
+	}
+}
+
+func TestElevationAt(t *testing.T) {
+	dir := t.TempDir()
+	writeTestTile(t, dir)
+
+	ts := NewTileSource(dir)
+	var _ Source = ts
+
+	tests := []struct{
+		Pos       geo.Latlong
+		Expected  float64 // metres
+	}{
+		{geo.Latlong{37.5, -123.0},   0},
+		{geo.Latlong{37.5, -122.5},  50},
+		{geo.Latlong{37.5, -122.55}, 45}, // Between samples
+		{geo.Latlong{37.0, -122.0}, 100}, // The south-east corner, on the edge of N37W122
+		{geo.Latlong{37.95, -122.05}, 93.33}, // Next to the void, which is skipped
+	}
+	for i,test := range tests {
+		actual,err := ts.ElevationAt(test.Pos)
+		if err != nil || math.Abs(actual - test.Expected*altitude.FeetPerMetre) > 0.05 {
+			t.Errorf("[%d] %s: expected %.1fft, saw %.1f (%v)", i, test.Pos,
+				test.Expected*altitude.FeetPerMetre, actual, err)
+		}
+	}
+
+	if agl,err := AGL(ts, geo.Latlong{37.5, -122.5}, 1000); err != nil || math.Abs(agl - (1000 - 50*altitude.FeetPerMetre)) > 0.01 {
+		t.Errorf("AGL: saw %.1f (%v)", agl, err)
+	}
+
+	// No tile here
+	if _,err := ts.ElevationAt(geo.Latlong{36.5, -122.5}); err == nil {
+		t.Errorf("expected an error for a missing tile")
+	}
+	ts.MissingIsSea = true
+	if elev,err := ts.ElevationAt(geo.Latlong{36.6, -122.5}); err != nil || elev != 0 {
+		t.Errorf("expected sea level for a missing tile, saw %.1f (%v)", elev, err)
+	}
+
+	// The cache should evict the oldest tiles when a new one is loaded
+	ts.MaxTiles = 1
+	ts.ElevationAt(geo.Latlong{35.5, -122.5})
+	if _,exists := ts.tiles[TileName(35,-123)]; !exists || len(ts.tiles) != 1 {
+		t.Errorf("cache not evicted: %v", ts.lru)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestTile(t, dir)
+	ts := NewTileSource(dir)
+
+	l := geo.Latlong{37.5, -123.0}.LineTo(geo.Latlong{37.5, -122.0})
+	prof,err := Profile(ts, l, 10.0)
+	if err != nil { t.Fatalf("Profile: %v", err) }
+
+	// ~88KM, so 9 steps and 10 points
+	if len(prof) != 10 { t.Fatalf("expected 10 points, saw %d", len(prof)) }
+	if prof[0].ElevationFeet != 0 || math.Abs(prof[9].ElevationFeet - 100*altitude.FeetPerMetre) > 0.01 {
+		t.Errorf("profile ends wrong: %v ... %v", prof[0], prof[9])
+	}
+	for i:=1; i<len(prof); i++ {
+		if prof[i].DistKM <= prof[i-1].DistKM || prof[i].ElevationFeet <= prof[i-1].ElevationFeet {
+			t.Errorf("profile not increasing at %d", i)
+		}
+	}
+
+	for _,stepKM := range []float64{0, -1, math.NaN()} {
+		if _,err := Profile(ts, l, stepKM); err == nil { t.Errorf("Profile: expected an error for step %v", stepKM) }
+	}
+}
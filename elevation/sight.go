@@ -0,0 +1,97 @@
+package elevation
+
+import(
+	"fmt"
+	"math"
+
+	"github.com/skypies/geo"
+	"github.com/skypies/geo/altitude"
+)
+
+const(
+	EarthRadiusFeet = 20902231.0 // Mean radius, 6371KM
+
+	// Radio and light bend down a little in the atmosphere, so they follow the earth's curve
+	// for a bit; this is modelled as an earth with a larger radius (k times the real one.)
+	KStandardRefraction = 4.0 / 3.0
+	KNoRefraction       = 1.0
+)
+
+// CurvatureDropFeet is how far the earth (with effective radius factor k) falls away below a
+// level line, distKM from where the line touches it.
+func CurvatureDropFeet(distKM, k float64) float64 {
+	d := distKM * 1000.0 * altitude.FeetPerMetre
+	return d*d / (2.0 * k * EarthRadiusFeet)
+}
+
+// SightResult describes the sight line between two points.
+type SightResult struct {
+	Visible        bool
+	ClearanceFeet  float64       // Smallest gap between the sight line and the terrain; -ve if blocked
+	Closest        ProfilePoint  // Where the terrain came closest to the line
+}
+
+func (sr SightResult)String() string {
+	str := "not visible"
+	if sr.Visible { str = "visible" }
+	return fmt.Sprintf("%s, clearance %.0fft at %.1fKM %s", str, sr.ClearanceFeet,
+		sr.Closest.DistKM, sr.Closest.Latlong)
+}
+
+// LineOfSight works out if an observer (observerAGL feet above the ground; e.g. 6ft for a
+// person, or the height of an ADS-B antenna) can see a target at targetMSL feet, using standard
+// refraction. The terrain is sampled every stepKM.
+func LineOfSight(src Source, observer geo.Latlong, observerAGL float64, target geo.Latlong, targetMSL, stepKM float64) (SightResult, error) {
+	return LineOfSightWithRefraction(src, observer, observerAGL, target, targetMSL, stepKM,
+		KStandardRefraction)
+}
+
+// LineOfSightWithRefraction uses k as the effective earth radius factor (KNoRefraction for
+// pure geometry.)
+func LineOfSightWithRefraction(src Source, observer geo.Latlong, observerAGL float64, target geo.Latlong, targetMSL, stepKM, k float64) (SightResult, error) {
+	prof,err := Profile(src, observer.LineTo(target), stepKM)
+	if err != nil { return SightResult{}, err }
+
+	totalKM := prof[len(prof)-1].DistKM
+	obsMSL := prof[0].ElevationFeet + observerAGL
+	sr := SightResult{Visible:true, ClearanceFeet:math.Inf(1)}
+
+	// Skip the endpoints; the observer and target are never blocked by their own ground
+	for _,pp := range prof[1:len(prof)-1] {
+		ratio := pp.DistKM / totalKM
+		lineMSL := obsMSL + (targetMSL - obsMSL)*ratio
+
+		// Relative to a straight line between the endpoints, the earth bulges upwards by
+		// x.(D-x)/2kR, at distance x along a line of length D
+		bulge := CurvatureDropFeet(math.Sqrt(pp.DistKM * (totalKM - pp.DistKM)), k)
+		if clearance := lineMSL - (pp.ElevationFeet + bulge); clearance < sr.ClearanceFeet {
+			sr.ClearanceFeet, sr.Closest = clearance, pp
+		}
+	}
+
+	if math.IsInf(sr.ClearanceFeet, 1) {
+		sr.ClearanceFeet, sr.Closest = 0, prof[0] // Too close to have any terrain between
+	} else {
+		sr.Visible = sr.ClearanceFeet > 0
+	}
+	return sr, nil
+}
+
+// MinTerrainClearance flies a straight segment from one 3D position to another (altitudes in
+// feet MSL, varying linearly), and reports the lowest height above the terrain, and where it
+// happened.
+func MinTerrainClearance(src Source, from geo.Latlong, fromMSL float64, to geo.Latlong, toMSL, stepKM float64) (float64, ProfilePoint, error) {
+	prof,err := Profile(src, from.LineTo(to), stepKM)
+	if err != nil { return 0, ProfilePoint{}, err }
+
+	totalKM := prof[len(prof)-1].DistKM
+	min,at := math.Inf(1), ProfilePoint{}
+	for _,pp := range prof {
+		ratio := 0.0
+		if totalKM > 0 { ratio = pp.DistKM / totalKM }
+		if clearance := fromMSL + (toMSL - fromMSL)*ratio - pp.ElevationFeet; clearance < min {
+			min,at = clearance, pp
+		}
+	}
+	return min, at, nil
+}
@@ -0,0 +1,78 @@
+package elevation
+
+import(
+	"math"
+	"testing"
+
+	"github.com/skypies/geo"
+)
+
+var seaLevel = SourceFunc(func(pos geo.Latlong) (float64, error) { return 0, nil })
+
+// ridgeAt returns terrain that is flat at sea level, except for a north-south ridge of the
+// given height, 1KM wide, centred on the longitude.
+func ridgeAt(long, heightFeet float64) Source {
+	return SourceFunc(func(pos geo.Latlong) (float64, error) {
+		if math.Abs(pos.DistKM(geo.Latlong{pos.Lat, long})) < 0.5 { return heightFeet, nil }
+		return 0, nil
+	})
+}
+
+func TestCurvatureDrop(t *testing.T) {
+	// The rule of thumb: the radio horizon (in NM) is about 1.23 x sqrt(height in feet)
+	nm := 1.23 * math.Sqrt(10000)
+	if drop := CurvatureDropFeet(geo.NM2KM(nm), KStandardRefraction); math.Abs(drop - 10000) > 300 {
+		t.Errorf("radio horizon: expected ~10000ft of drop at %.0fNM, saw %.0f", nm, drop)
+	}
+}
+
+func TestLineOfSight(t *testing.T) {
+	observer := geo.Latlong{37.0, -122.0}
+	east := func(km float64) geo.Latlong { return observer.MoveKM(90, km) }
+
+	tests := []struct{
+		Src        Source
+		Target     geo.Latlong
+		TargetMSL  float64
+		K          float64
+		Expected   bool
+	}{
+		{seaLevel, east(50),  500, KStandardRefraction, true},
+		{seaLevel, east(50),  100, KStandardRefraction, false}, // Over the horizon
+		{seaLevel, east(33),  180, KNoRefraction,       false}, // Horizons are 4.8+26.4KM
+		{seaLevel, east(33),  180, KStandardRefraction, true},  // Refraction gives ~15% more range
+		{seaLevel, east(0.05), 10, KStandardRefraction, true},  // No samples in between
+		{ridgeAt(east(10).Long, 2000), east(20), 1500, KStandardRefraction, false},
+		{ridgeAt(east(10).Long, 2000), east(20), 5000, KStandardRefraction, true},
+	}
+	for i,test := range tests {
+		sr,err := LineOfSightWithRefraction(test.Src, observer, 6, test.Target, test.TargetMSL,
+			0.25, test.K)
+		if err != nil { t.Errorf("[%d] error: %v", i, err); continue }
+		if sr.Visible != test.Expected {
+			t.Errorf("[%d] expected visible=%v, saw %s", i, test.Expected, sr)
+		}
+	}
+
+	sr,_ := LineOfSight(ridgeAt(east(10).Long, 2000), observer, 6, east(20), 1500, 0.25)
+	if math.Abs(sr.Closest.DistKM - 10) > 0.5 || sr.ClearanceFeet > -1000 {
+		t.Errorf("ridge: expected to be blocked by ~1250ft at 10KM, saw %s", sr)
+	}
+}
+
+func TestMinTerrainClearance(t *testing.T) {
+	from := geo.Latlong{37.0, -122.0}
+	to := from.MoveKM(90, 20)
+	src := ridgeAt(from.MoveKM(90, 5).Long, 2000)
+
+	// Descending from 4000 to 2000ft; we're lowest over the far side of the ridge, at 5.5KM
+	clearance,at,err := MinTerrainClearance(src, from, 4000, to, 2000, 0.1)
+	if err != nil { t.Fatalf("error: %v", err) }
+	if math.Abs(clearance - 1450) > 20 || math.Abs(at.DistKM - 5.5) > 0.1 {
+		t.Errorf("expected ~1450ft at ~5.5KM, saw %.0fft at %.1fKM", clearance, at.DistKM)
+	}
+
+	if clearance,_,_ := MinTerrainClearance(seaLevel, from, 4000, to, 2000, 1); clearance != 2000 {
+		t.Errorf("over the sea: expected 2000ft at the end, saw %.0f", clearance)
+	}
+}
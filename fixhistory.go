@@ -0,0 +1,113 @@
+package geo
+
+import(
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FixHistory holds every version of each fix, each in force over its own date range (see
+// FixEntry.Effective and Until); e.g. EDDYY moved when SERFR3 replaced SERFR2. Lookups take a
+// time, such as that of a flight, and return the version in force then. Load it up before use;
+// it is safe for concurrent reads, but not for reads during modification.
+type FixHistory struct {
+	versions map[string][]FixEntry // keyed by upper-case name; sorted by Effective
+	aliases  map[string][]string   // upper-case alias to the names with a version that uses it
+}
+
+func NewFixHistory() *FixHistory {
+	return &FixHistory{versions:map[string][]FixEntry{}, aliases:map[string][]string{}}
+}
+
+func (fh *FixHistory)String() string { return fmt.Sprintf("FixHistory (%d fixes)", len(fh.versions)) }
+
+// Add inserts a version of a fix. It fails if the date range overlaps with that of another
+// version of the same name.
+func (fh *FixHistory)Add(fe FixEntry) error {
+	fe.Name = strings.ToUpper(strings.TrimSpace(fe.Name))
+	if fe.Name == "" { return fmt.Errorf("fix has no name: %s", fe) }
+	if !fe.Until.IsZero() && !fe.Until.After(fe.Effective) {
+		return fmt.Errorf("fix %s: until is not after effective", fe)
+	}
+
+	vs := fh.versions[fe.Name]
+	for _,v := range vs {
+		if rangesOverlap(v.Effective, v.Until, fe.Effective, fe.Until) {
+			return fmt.Errorf("fix %s overlaps with %s", fe, v)
+		}
+	}
+	vs = append(vs, fe)
+	sort.Slice(vs, func(i,j int) bool { return vs[i].Effective.Before(vs[j].Effective) })
+	fh.versions[fe.Name] = vs
+
+	for _,alias := range fe.Aliases {
+		alias = strings.ToUpper(strings.TrimSpace(alias))
+		names := fh.aliases[alias]
+		if len(names) == 0 || names[len(names)-1] != fe.Name { fh.aliases[alias] = append(names, fe.Name) }
+	}
+	return nil
+}
+
+// AddRegistry adds all the entries from the registry.
+func (fh *FixHistory)AddRegistry(fr *FixRegistry) error {
+	for _,fe := range fr.Entries() {
+		if err := fh.Add(fe); err != nil { return err }
+	}
+	return nil
+}
+
+// Versions returns all the versions of the named fix, oldest first.
+func (fh *FixHistory)Versions(name string) []FixEntry {
+	return append([]FixEntry{}, fh.versions[strings.ToUpper(strings.TrimSpace(name))]...)
+}
+
+// EntryAt returns the version of the fix (looked up by name, or alias) in force at the time.
+func (fh *FixHistory)EntryAt(name string, t time.Time) (FixEntry, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for _,fe := range fh.versions[name] {
+		if fe.InForceAt(t) { return fe, true }
+	}
+	for _,canonical := range fh.aliases[name] {
+		for _,fe := range fh.versions[canonical] {
+			if !fe.InForceAt(t) { continue }
+			for _,alias := range fe.Aliases {
+				if strings.ToUpper(strings.TrimSpace(alias)) == name { return fe, true }
+			}
+		}
+	}
+	return FixEntry{}, false
+}
+
+func (fh *FixHistory)LookupAt(name string, t time.Time) (NamedLatlong, bool) {
+	fe,exists := fh.EntryAt(name, t)
+	return fe.NamedLatlong, exists
+}
+
+// RegistryAt returns a registry of the fixes in force at the time. Aliases that clash are
+// dropped.
+func (fh *FixHistory)RegistryAt(t time.Time) *FixRegistry {
+	fr := NewFixRegistry()
+	for _,vs := range fh.versions {
+		for _,fe := range vs {
+			if fe.InForceAt(t) { fe.Aliases = nil; fr.Add(fe) }
+		}
+	}
+	for _,vs := range fh.versions {
+		for _,fe := range vs {
+			if !fe.InForceAt(t) { continue }
+			for _,alias := range fe.Aliases { fr.AddAlias(alias, fe.Name) }
+		}
+	}
+	return fr
+}
+
+// At returns a FixLookup for the fixes in force at the time; e.g. to populate a procedure:
+//   p.Populate(fh.At(p.Effective))
+func (fh *FixHistory)At(t time.Time) FixLookup { return fixHistoryAt{fh, t} }
+
+type fixHistoryAt struct {
+	fh *FixHistory
+	t  time.Time
+}
+func (fha fixHistoryAt)Lookup(name string) (NamedLatlong, bool) { return fha.fh.LookupAt(name, fha.t) }
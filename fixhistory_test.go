@@ -0,0 +1,73 @@
+package geo
+
+import(
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFixHistoryCSV = `name, lat, long, effective, until, aliases
+EPICK, 36.9508222, -121.9526722
+EDDYY, 37.3264500, -122.0997083, , 2016-03-31
+EDDYY, 37.3749028, -122.1187500, 2016-03-31, , EDDYY3
+NARWL, 37.2747806, -122.0792944, 2016-03-31
+`
+
+func TestFixHistory(t *testing.T) {
+	// A registry can't hold two EDDYYs, so load them one at a time
+	fh := NewFixHistory()
+	lines := strings.Split(strings.TrimSpace(testFixHistoryCSV), "\n")
+	for _,line := range lines[1:] {
+		fr := NewFixRegistry()
+		if err := fr.LoadCSV(strings.NewReader(lines[0]+"\n"+line), "test.csv"); err != nil { t.Fatal(err) }
+		if err := fh.AddRegistry(fr); err != nil { t.Fatal(err) }
+	}
+
+	before := time.Date(2016, 3, 30, 23, 59, 0, 0, time.UTC)
+	after := AIRACCycle(1604).Effective()
+
+	tests := []struct{
+		Name      string
+		T         time.Time
+		Expected  Latlong // nil if not in force
+	}{
+		{"EPICK",  before, Latlong{36.9508222, -121.9526722}},
+		{"epick",  after,  Latlong{36.9508222, -121.9526722}},
+		{"EDDYY",  before, Latlong{37.3264500, -122.0997083}},
+		{"EDDYY",  after,  Latlong{37.3749028, -122.1187500}},
+		{"EDDYY3", before, Latlong{}},
+		{"EDDYY3", after,  Latlong{37.3749028, -122.1187500}},
+		{"NARWL",  before, Latlong{}},
+		{"NARWL",  after,  Latlong{37.2747806, -122.0792944}},
+	}
+	for i,test := range tests {
+		nl,exists := fh.LookupAt(test.Name, test.T)
+		if exists == test.Expected.IsNil() || !nl.Latlong.Equal(test.Expected) {
+			t.Errorf("[%d] %s at %s: expected %s, saw %s (%v)", i, test.Name, test.T, test.Expected, nl, exists)
+		}
+	}
+
+	if n := len(fh.Versions("eddyy")); n != 2 { t.Errorf("expected 2 versions of EDDYY, saw %d", n) }
+	if fr := fh.RegistryAt(before); fr.Len() != 2 { t.Errorf("RegistryAt(before): saw %v", fr.Names()) }
+	if fr := fh.RegistryAt(after); fr.Len() != 3 { t.Errorf("RegistryAt(after): saw %v", fr.Names()) }
+
+	clash := FixEntry{NamedLatlong:NamedLatlong{"EDDYY", Latlong{37,-122}}, Effective:after.AddDate(0,1,0)}
+	if err := fh.Add(clash); err == nil { t.Errorf("expected an error for an overlapping version") }
+
+	// Procedures, populated from the fixes in force when they were published
+	ps := ProcedureSet{
+		{Name:"SERFR2", Until:after, Waypoints:[]Waypoint{{FixName:"EPICK"}, {FixName:"EDDYY"}}},
+		{Name:"SERFR3", Effective:after, Waypoints:[]Waypoint{{FixName:"EPICK"}, {FixName:"NARWL"}, {FixName:"EDDYY"}}},
+	}
+	for i,_ := range ps {
+		if err := ps[i].Populate(fh.At(ps[i].Effective)); err != nil { t.Errorf("Populate: %v", err) }
+	}
+
+	if _,exists := ps.Lookup("SERFR3", before); exists { t.Errorf("SERFR3 was not in force before") }
+	if p,_ := ps.Lookup("SERFR2", before); !p.Waypoints[1].Equal(Latlong{37.3264500, -122.0997083}) {
+		t.Errorf("SERFR2: bad EDDYY %s", p.Waypoints[1].Latlong)
+	}
+	if inForce := ps.InForce(after); len(inForce) != 1 || inForce[0].Name != "SERFR3" {
+		t.Errorf("InForce(after): saw %v", inForce)
+	}
+}
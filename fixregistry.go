@@ -0,0 +1,440 @@
+package geo
+
+import(
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FixLookup finds named positions (fixes, navaids, airports). Names are case-insensitive; the
+// returned NamedLatlong carries the canonical name.
+type FixLookup interface {
+	Lookup(name string) (NamedLatlong, bool)
+}
+
+// FixMap adapts a plain map (with upper-case keys) into a FixLookup.
+type FixMap map[string]Latlong
+func (fm FixMap)Lookup(name string) (NamedLatlong, bool) {
+	name = strings.ToUpper(name)
+	pos,exists := fm[name]
+	return NamedLatlong{name, pos}, exists
+}
+
+// FixEntry is a fix, with some metadata about where it came from.
+type FixEntry struct {
+	NamedLatlong             // embed
+	Type       string        // e.g. "waypoint", "airport", "navaid", "personal"
+	Source     string        // e.g. the file it was loaded from
+	Effective  time.Time     // When the fix came into effect; zero if not known
+	Until      time.Time     // When it was withdrawn (or moved); zero if still in force
+	Aliases  []string        // Other names for the fix
+}
+
+func (fe FixEntry)String() string {
+	str := fmt.Sprintf("%s %s", fe.Name, fe.Latlong)
+	if fe.Type != "" { str += " " + fe.Type }
+	if len(fe.Aliases) > 0 { str += fmt.Sprintf(" aka %v", fe.Aliases) }
+	if fe.Source != "" { str += " (" + fe.Source + ")" }
+	if !fe.Until.IsZero() { str += " until " + fe.Until.Format(kFixDateFormat) }
+	return str
+}
+
+// InForceAt says whether the fix was defined at the time.
+func (fe FixEntry)InForceAt(t time.Time) bool { return inForce(fe.Effective, fe.Until, t) }
+
+// FixConflict reports an incoming entry that clashed with one already in the registry. The
+// existing entry is kept.
+type FixConflict struct {
+	Name              string
+	Existing,Incoming FixEntry
+	DistKM            float64 // Zero if the clash was over an alias
+}
+
+func (fc FixConflict)String() string {
+	return fmt.Sprintf("%s: %s vs %s (%.2fKM apart)", fc.Name, fc.Existing, fc.Incoming, fc.DistKM)
+}
+
+// FixRegistry is a set of fixes, with case-insensitive lookup by name or alias. Load it up
+// before use; it is safe for concurrent reads, but not for reads during modification.
+type FixRegistry struct {
+	entries map[string]FixEntry // keyed by upper-case name
+	aliases map[string]string   // upper-case alias to upper-case name
+}
+
+func NewFixRegistry() *FixRegistry {
+	return &FixRegistry{entries:map[string]FixEntry{}, aliases:map[string]string{}}
+}
+
+// NewFixRegistryFromMap creates a registry with the same type and source for all entries. Names
+// that clash (e.g. differing only in case) are reported as conflicts.
+func NewFixRegistryFromMap(m map[string]Latlong, fixType, source string) (*FixRegistry, []FixConflict) {
+	fr := NewFixRegistry()
+	conflicts := []FixConflict{}
+	names := []string{}
+	for name,_ := range m { names = append(names, name) }
+	sort.Strings(names) // So that the same one wins each time
+	for _,name := range names {
+		fe := FixEntry{NamedLatlong:NamedLatlong{name,m[name]}, Type:fixType, Source:source}
+		conflicts = append(conflicts, fr.AddOrConflict(fe)...)
+	}
+	return fr, conflicts
+}
+
+func (fr *FixRegistry)String() string { return fmt.Sprintf("FixRegistry (%d fixes)", fr.Len()) }
+func (fr *FixRegistry)Len() int { return len(fr.entries) }
+
+func (fr *FixRegistry)resolve(name string) string {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if canonical,exists := fr.aliases[name]; exists { return canonical }
+	return name
+}
+
+// Add inserts the entry, and its aliases. It fails if the name, or any of the aliases, is
+// already in use; in which case the registry is left unchanged.
+func (fr *FixRegistry)Add(fe FixEntry) error {
+	fe.Name = strings.ToUpper(strings.TrimSpace(fe.Name))
+	if fe.Name == "" { return fmt.Errorf("fix has no name: %s", fe) }
+	if fr.isTaken(fe.Name) { return fmt.Errorf("fix %s already exists", fe.Name) }
+
+	aliases := []string{}
+	seen := map[string]bool{fe.Name:true}
+	for _,alias := range fe.Aliases {
+		alias = strings.ToUpper(strings.TrimSpace(alias))
+		if alias == "" || seen[alias] { continue }
+		if fr.isTaken(alias) { return fmt.Errorf("alias %s for %s: name already in use", alias, fe.Name) }
+		seen[alias] = true
+		aliases = append(aliases, alias)
+	}
+
+	fe.Aliases = aliases
+	fr.entries[fe.Name] = fe
+	for _,alias := range aliases { fr.aliases[alias] = fe.Name }
+	return nil
+}
+
+// AddOrConflict is Add, but reports a failure as a conflict with the entry that already holds
+// the name (or one of the aliases); it returns no conflicts if the entry was added.
+func (fr *FixRegistry)AddOrConflict(fe FixEntry) []FixConflict {
+	if err := fr.Add(fe); err == nil { return nil }
+
+	name := strings.ToUpper(strings.TrimSpace(fe.Name))
+	if existing,exists := fr.Entry(name); exists {
+		return []FixConflict{{name, existing, fe, existing.DistKM(fe.Latlong)}}
+	}
+	for _,alias := range fe.Aliases {
+		alias = strings.ToUpper(strings.TrimSpace(alias))
+		if existing,exists := fr.Entry(alias); exists {
+			return []FixConflict{{alias, existing, fe, 0}}
+		}
+	}
+	return []FixConflict{{Name:name, Incoming:fe}} // No name
+}
+
+// addAll adds all of the entries, or none of them; if one fails, those already added are
+// removed again. The error is labelled with label(i) for the entry that failed.
+func (fr *FixRegistry)addAll(fes []FixEntry, label func(i int) string) error {
+	for i,fe := range fes {
+		if err := fr.Add(fe); err != nil {
+			for _,added := range fes[:i] {
+				name := strings.ToUpper(strings.TrimSpace(added.Name))
+				for _,alias := range fr.entries[name].Aliases { delete(fr.aliases, alias) }
+				delete(fr.entries, name)
+			}
+			return fmt.Errorf("%s: %v", label(i), err)
+		}
+	}
+	return nil
+}
+
+func (fr *FixRegistry)isTaken(name string) bool {
+	_,isEntry := fr.entries[name]
+	_,isAlias := fr.aliases[name]
+	return isEntry || isAlias
+}
+
+// AddAlias makes alias another name for an existing fix.
+func (fr *FixRegistry)AddAlias(alias, name string) error {
+	alias = strings.ToUpper(strings.TrimSpace(alias))
+	canonical := fr.resolve(name)
+
+	fe,exists := fr.entries[canonical]
+	if !exists { return fmt.Errorf("alias %s: fix %s not known", alias, name) }
+	if alias == "" || alias == canonical || fr.aliases[alias] == canonical { return nil }
+	if fr.isTaken(alias) { return fmt.Errorf("alias %s for %s: name already in use", alias, canonical) }
+
+	fr.aliases[alias] = canonical
+	fe.Aliases = append(fe.Aliases, alias)
+	fr.entries[canonical] = fe
+	return nil
+}
+
+// Entry returns the full entry for the name (or alias).
+func (fr *FixRegistry)Entry(name string) (FixEntry, bool) {
+	fe,exists := fr.entries[fr.resolve(name)]
+	return fe, exists
+}
+
+// Lookup implements FixLookup.
+func (fr *FixRegistry)Lookup(name string) (NamedLatlong, bool) {
+	fe,exists := fr.Entry(name)
+	return fe.NamedLatlong, exists
+}
+
+// Names returns the canonical names of all the fixes, sorted.
+func (fr *FixRegistry)Names() []string {
+	ret := []string{}
+	for name,_ := range fr.entries { ret = append(ret, name) }
+	sort.Strings(ret)
+	return ret
+}
+
+// Entries returns all the entries, sorted by name.
+func (fr *FixRegistry)Entries() []FixEntry {
+	ret := []FixEntry{}
+	for _,name := range fr.Names() { ret = append(ret, fr.entries[name]) }
+	return ret
+}
+
+// Map returns a copy of the fixes as a plain map (aliases are not included).
+func (fr *FixRegistry)Map() map[string]Latlong {
+	ret := map[string]Latlong{}
+	for name,fe := range fr.entries { ret[name] = fe.Latlong }
+	return ret
+}
+
+// Merge adds the entries from other. If a name is already present at (about) the same position
+// (within tolKM), the entries are treated as the same fix and any new aliases are added; if it
+// is somewhere else, the existing entry is kept, and the clash is reported as a conflict.
+func (fr *FixRegistry)Merge(other *FixRegistry, tolKM float64) []FixConflict {
+	conflicts := []FixConflict{}
+
+	for _,in := range other.Entries() {
+		existing,exists := fr.Entry(in.Name)
+		if !exists {
+			in.Aliases = nil // Added one at a time below, so clashes can be reported
+			if c := fr.AddOrConflict(in); len(c) > 0 {
+				conflicts = append(conflicts, c...)
+				continue
+			}
+			existing = fr.entries[in.Name]
+		} else if dist := existing.DistKM(in.Latlong); dist > tolKM {
+			conflicts = append(conflicts, FixConflict{in.Name, existing, in, dist})
+			continue
+		}
+
+		for _,alias := range other.entries[in.Name].Aliases {
+			if fr.resolve(alias) == existing.Name { continue }
+			if err := fr.AddAlias(alias, existing.Name); err != nil {
+				clash,_ := fr.Entry(alias)
+				conflicts = append(conflicts, FixConflict{alias, clash, in, 0})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// NewFixRegistryFromEntries builds a registry for a region from the entries inside the box (or
+// from all of them, if the box is nil); e.g. from the entries read by ReadNavDataFile. Clashing
+// names are handled as for Merge; entries that can't be added at all (e.g. with no name) are
+// also reported as conflicts.
+func NewFixRegistryFromEntries(entries []FixEntry, box LatlongBox, tolKM float64) (*FixRegistry, []FixConflict) {
+	fr := NewFixRegistry()
+	conflicts := []FixConflict{}
+	for _,fe := range entries {
+		if !box.IsNil() && !box.Contains(fe.Latlong) { continue }
+		single := NewFixRegistry()
+		if c := single.AddOrConflict(fe); len(c) > 0 {
+			conflicts = append(conflicts, c...)
+			continue
+		}
+		conflicts = append(conflicts, fr.Merge(single, tolKM)...)
+	}
+	return fr, conflicts
+}
+
+// {{{ FixTable
+
+// FixTable is a read-only snapshot of a registry. Nothing can modify it, so it is safe for
+// concurrent use (e.g. by many HTTP handlers.)
+type FixTable struct {
+	fr *FixRegistry
+}
+
+// Table takes a deep copy of the registry; later changes to the registry do not affect it.
+func (fr *FixRegistry)Table() FixTable {
+	c := NewFixRegistry()
+	for name,fe := range fr.entries {
+		fe.Aliases = append([]string{}, fe.Aliases...)
+		c.entries[name] = fe
+	}
+	for alias,name := range fr.aliases { c.aliases[alias] = name }
+	return FixTable{c}
+}
+
+func (ft FixTable)String() string { return fmt.Sprintf("FixTable (%d fixes)", ft.Len()) }
+func (ft FixTable)Len() int {
+	if ft.fr == nil { return 0 }
+	return ft.fr.Len()
+}
+
+func (ft FixTable)Lookup(name string) (NamedLatlong, bool) {
+	if ft.fr == nil { return NamedLatlong{}, false }
+	return ft.fr.Lookup(name)
+}
+
+// Entry returns a copy of the entry, so the aliases can't be modified via it.
+func (ft FixTable)Entry(name string) (FixEntry, bool) {
+	if ft.fr == nil { return FixEntry{}, false }
+	fe,exists := ft.fr.Entry(name)
+	fe.Aliases = append([]string{}, fe.Aliases...)
+	return fe, exists
+}
+
+func (ft FixTable)Names() []string {
+	if ft.fr == nil { return []string{} }
+	return ft.fr.Names()
+}
+
+// Map returns a copy, which the caller may modify.
+func (ft FixTable)Map() map[string]Latlong {
+	if ft.fr == nil { return map[string]Latlong{} }
+	return ft.fr.Map()
+}
+
+// Registry returns a new, modifiable, copy of the table.
+func (ft FixTable)Registry() *FixRegistry {
+	if ft.fr == nil { return NewFixRegistry() }
+	return ft.fr.Table().fr
+}
+
+// }}}
+// {{{ LoadCSV, LoadJSON, LoadFile
+
+const kFixDateFormat = "2006-01-02"
+
+// parseFixDate returns a zero time for an empty string.
+func parseFixDate(s string) (time.Time, error) {
+	if s == "" { return time.Time{}, nil }
+	return time.Parse(kFixDateFormat, s)
+}
+
+// LoadCSV reads fixes from CSV with a header row. The columns name, lat and long are required;
+// type, source, effective and until (YYYY-MM-DD), and aliases (separated by spaces or
+// semicolons) are optional. If an entry has no source, the given source is used. If any row is
+// bad, none are added.
+func (fr *FixRegistry)LoadCSV(r io.Reader, source string) error {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1 // Trailing optional columns may be left off
+	rows,err := cr.ReadAll()
+	if err != nil { return fmt.Errorf("%s: %v", source, err) }
+	if len(rows) == 0 { return nil }
+
+	cols := map[string]int{}
+	for i,col := range rows[0] { cols[strings.ToLower(strings.TrimSpace(col))] = i }
+	for _,required := range []string{"name", "lat", "long"} {
+		if _,exists := cols[required]; !exists {
+			return fmt.Errorf("%s: no '%s' column in header", source, required)
+		}
+	}
+
+	fes := []FixEntry{}
+	for i,row := range rows[1:] {
+		get := func(col string) string {
+			if j,exists := cols[col]; exists && j < len(row) { return strings.TrimSpace(row[j]) }
+			return ""
+		}
+
+		fe := FixEntry{Type:get("type"), Source:get("source")}
+		fe.Name = get("name")
+		lat,err1 := strconv.ParseFloat(get("lat"), 64)
+		long,err2 := strconv.ParseFloat(get("long"), 64)
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("%s: row %d: bad lat/long", source, i+2)
+		}
+		fe.Latlong = Latlong{lat, long}
+		if fe.Effective,err = parseFixDate(get("effective")); err != nil {
+			return fmt.Errorf("%s: row %d: %v", source, i+2, err)
+		}
+		if fe.Until,err = parseFixDate(get("until")); err != nil {
+			return fmt.Errorf("%s: row %d: %v", source, i+2, err)
+		}
+		fe.Aliases = strings.FieldsFunc(get("aliases"), func(r rune) bool { return r==' ' || r==';' })
+
+		if fe.Source == "" { fe.Source = source }
+		fes = append(fes, fe)
+	}
+	return fr.addAll(fes, func(i int) string { return fmt.Sprintf("%s: row %d", source, i+2) })
+}
+
+type jsonFixEntry struct {
+	Name       string     `json:"name"`
+	Lat        float64    `json:"lat"`
+	Long       float64    `json:"long"`
+	Type       string     `json:"type,omitempty"`
+	Source     string     `json:"source,omitempty"`
+	Effective  string     `json:"effective,omitempty"`
+	Until      string     `json:"until,omitempty"`
+	Aliases  []string     `json:"aliases,omitempty"`
+}
+
+// LoadJSON reads a JSON array of objects, with the same fields as LoadCSV. If any entry is bad,
+// none are added.
+func (fr *FixRegistry)LoadJSON(r io.Reader, source string) error {
+	jsonEntries := []jsonFixEntry{}
+	if err := json.NewDecoder(r).Decode(&jsonEntries); err != nil {
+		return fmt.Errorf("%s: %v", source, err)
+	}
+
+	fes := []FixEntry{}
+	for i,je := range jsonEntries {
+		fe := FixEntry{
+			NamedLatlong: NamedLatlong{je.Name, Latlong{je.Lat, je.Long}},
+			Type: je.Type,
+			Source: je.Source,
+			Aliases: je.Aliases,
+		}
+		var err error
+		if fe.Effective,err = parseFixDate(je.Effective); err != nil {
+			return fmt.Errorf("%s: entry %d: %v", source, i, err)
+		}
+		if fe.Until,err = parseFixDate(je.Until); err != nil {
+			return fmt.Errorf("%s: entry %d: %v", source, i, err)
+		}
+		if fe.Source == "" { fe.Source = source }
+		fes = append(fes, fe)
+	}
+	return fr.addAll(fes, func(i int) string { return fmt.Sprintf("%s: entry %d", source, i) })
+}
+
+// LoadFile picks LoadCSV or LoadJSON based on the file extension; the filename is the source.
+func (fr *FixRegistry)LoadFile(filename string) error {
+	f,err := os.Open(filename)
+	if err != nil { return err }
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":  return fr.LoadCSV(f, filename)
+	case ".json": return fr.LoadJSON(f, filename)
+	default:      return fmt.Errorf("%s: unknown file type", filename)
+	}
+}
+
+// }}}
+
+// {{{ -------------------------={ E N D }=----------------------------------
+
+// Local variables:
+// folded-file: t
+// end:
+
+// }}}
@@ -0,0 +1,152 @@
+package geo
+
+import(
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFixCSV = `name, lat, long, type, source, effective, aliases
+# A comment
+SERFR, 36.0683056, -121.3646639, waypoint, , 2015-03-05, SERFR2
+EPICK, 36.9508222, -121.9526722
+KSFO,  37.6188172, -122.3754281, airport, faa, , SFO;Sfo
+`
+
+var testFixJSON = `[
+  {"name": "epick", "lat": 36.9508222, "long": -121.9526722, "aliases": ["EPIK"]},
+  {"name": "SERFR", "lat": 36.5,       "long": -121.0},
+  {"name": "MENLO", "lat": 37.4636861, "long": -122.1536583, "aliases": ["SFO"]}
+]`
+
+func TestFixRegistry(t *testing.T) {
+	fr := NewFixRegistry()
+	if err := fr.LoadCSV(strings.NewReader(testFixCSV), "test.csv"); err != nil {
+		t.Fatalf("LoadCSV: %v", err)
+	}
+	if fr.Len() != 3 { t.Errorf("expected 3 fixes, saw %d", fr.Len()) }
+
+	lookupTests := []struct{
+		Name     string
+		Expected string // canonical name; empty if not found
+	}{
+		{"SERFR",  "SERFR"},
+		{"serfr2", "SERFR"},
+		{"Epick",  "EPICK"},
+		{"sfo",    "KSFO"},
+		{"MENLO",  ""},
+	}
+	for i,test := range lookupTests {
+		nl,exists := fr.Lookup(test.Name)
+		if exists != (test.Expected != "") || nl.Name != test.Expected {
+			t.Errorf("[%d] Lookup(%s): expected '%s', saw '%s' (%v)", i, test.Name, test.Expected,
+				nl.Name, exists)
+		}
+	}
+
+	fe,_ := fr.Entry("SFO")
+	if fe.Type != "airport" || fe.Source != "faa" || len(fe.Aliases) != 1 {
+		t.Errorf("bad KSFO entry: %s", fe)
+	}
+	fe,_ = fr.Entry("SERFR")
+	if fe.Source != "test.csv" || !fe.Effective.Equal(time.Date(2015,3,5,0,0,0,0,time.UTC)) {
+		t.Errorf("bad SERFR entry: %s, %s", fe, fe.Effective)
+	}
+
+	if err := fr.Add(FixEntry{NamedLatlong:NamedLatlong{"sfo", Latlong{1,1}}}); err == nil {
+		t.Errorf("expected an error adding a name that is an alias")
+	}
+	if err := fr.AddAlias("XYZ", "NOSUCH"); err == nil {
+		t.Errorf("expected an error aliasing an unknown fix")
+	}
+
+	// A clashing alias leaves the registry as it was
+	clash := FixEntry{NamedLatlong:NamedLatlong{"WWAVS", Latlong{36.74,-121.89}}, Aliases:[]string{"WAVES","SFO"}}
+	if err := fr.Add(clash); err == nil { t.Errorf("expected an error for a clashing alias") }
+	if _,exists := fr.Lookup("WWAVS"); exists { t.Errorf("WWAVS was added, despite its alias clashing") }
+	if _,exists := fr.Lookup("WAVES"); exists { t.Errorf("WAVES was added, despite SFO clashing") }
+	if c := fr.AddOrConflict(clash); len(c) != 1 || c[0].Name != "SFO" || c[0].Existing.Name != "KSFO" {
+		t.Errorf("AddOrConflict: saw %v", c)
+	}
+	if c := fr.AddOrConflict(FixEntry{NamedLatlong:NamedLatlong{"epick", Latlong{1,1}}}); len(c) != 1 || c[0].DistKM < 100 {
+		t.Errorf("AddOrConflict: saw %v", c)
+	}
+	if c := fr.AddOrConflict(FixEntry{}); len(c) != 1 { t.Errorf("AddOrConflict: no conflict for no name") }
+	if fr.Len() != 3 { t.Errorf("expected 3 fixes after failed adds, saw %d", fr.Len()) }
+
+	// Merging: EPICK is the same place (but brings an alias); SERFR has moved; MENLO is new,
+	// but its alias clashes with KSFO's
+	other := NewFixRegistry()
+	if err := other.LoadJSON(strings.NewReader(testFixJSON), "test.json"); err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+	conflicts := fr.Merge(other, 0.1)
+
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, saw %v", conflicts)
+	}
+	if c := conflicts[1]; c.Name != "SERFR" || c.DistKM < 50 || c.Existing.Source != "test.csv" {
+		t.Errorf("bad SERFR conflict: %s", c)
+	}
+	if c := conflicts[0]; c.Name != "SFO" || c.Existing.Name != "KSFO" || c.Incoming.Name != "MENLO" {
+		t.Errorf("bad alias conflict: %s", c)
+	}
+	if nl,_ := fr.Lookup("EPIK"); nl.Name != "EPICK" { t.Errorf("merged alias missing") }
+	if nl,_ := fr.Lookup("SERFR"); nl.Lat != 36.0683056 { t.Errorf("conflict overwrote SERFR") }
+	if _,exists := fr.Lookup("MENLO"); !exists { t.Errorf("MENLO not merged") }
+
+	if names := fr.Names(); strings.Join(names, ",") != "EPICK,KSFO,MENLO,SERFR" {
+		t.Errorf("Names: saw %v", names)
+	}
+}
+
+func TestFixRegistryLoadErrors(t *testing.T) {
+	for i,test := range []string{
+		"name,lat\nA,1\n",
+		"name,lat,long\nA,x,1\n",
+		"name,lat,long\nA,1,1\na,2,2\n",
+		"name,lat,long,effective\nA,1,1,yesterday\n",
+		"name,lat,long,aliases\nA,1,1,Z\nB,2,2\nC,3,3,Z\n", // Late clash on an alias
+	} {
+		fr := NewFixRegistry()
+		fr.Add(FixEntry{NamedLatlong:NamedLatlong{"KEEP", Latlong{1,1}}})
+		if err := fr.LoadCSV(strings.NewReader(test), "bad"); err == nil {
+			t.Errorf("[%d] expected an error", i)
+		}
+		if fr.Len() != 1 || fr.isTaken("Z") { t.Errorf("[%d] registry partly loaded: %v", i, fr.Names()) }
+	}
+
+	fr := NewFixRegistry()
+	if err := fr.LoadJSON(strings.NewReader(`{"name":"A"}`), "bad"); err == nil {
+		t.Errorf("expected an error for non-array JSON")
+	}
+	json := `[{"name":"A", "lat":1, "long":1}, {"name":"B", "lat":2, "long":2, "until":"soon"}]`
+	if err := fr.LoadJSON(strings.NewReader(json), "bad"); err == nil || fr.Len() != 0 {
+		t.Errorf("expected an error, and nothing loaded; saw %v, %v", err, fr.Names())
+	}
+	json = `[{"name":"A", "lat":1, "long":1}, {"name":"a", "lat":2, "long":2}]`
+	if err := fr.LoadJSON(strings.NewReader(json), "bad"); err == nil || fr.Len() != 0 {
+		t.Errorf("expected an error, and nothing loaded; saw %v, %v", err, fr.Names())
+	}
+}
+
+func TestFixLookupUsers(t *testing.T) {
+	fr := NewFixRegistry()
+	fr.LoadCSV(strings.NewReader(testFixCSV), "test.csv")
+
+	p := Procedure{Name:"TEST", Waypoints:[]Waypoint{{FixName:"SERFR"}, {FixName:"EPICK"}}}
+	if err := p.Populate(fr); err != nil || p.Waypoints[1].Lat != 36.9508222 {
+		t.Errorf("Populate: %v, %v", err, p.Waypoints)
+	}
+	p.Waypoints = append(p.Waypoints, Waypoint{FixName:"NOSUCH"})
+	if err := p.Populate(fr); err == nil {
+		t.Errorf("Populate: expected an error for an unknown fix")
+	}
+
+	r,_ := http.NewRequest("GET", "/?"+url.Values{"pos_name":{"sfo"}}.Encode(), nil)
+	if nl := FormValueNamedLatlong(r, fr, "pos"); nl.Name != "KSFO" || nl.Lat != 37.6188172 {
+		t.Errorf("FormValueNamedLatlong: saw %s", nl)
+	}
+}
@@ -1,5 +1,7 @@
 package geo
 
+import "math"
+
 // A few crappy routines for handling arithmetic on headings
 
 // This float is supposed to range from [0.0,wrap) - i.e. a heading, [0,360)
@@ -29,3 +31,12 @@ func HeadingDelta(from, to float64) float64 {
 	if delta >= 180.0 { delta -= 360.0 }
 	return delta
 }
+
+// BearingInRange says whether the bearing lies within the arc that runs clockwise from start to
+// end; the arc may wrap through north (e.g. 330->030). If start and end are the same, or are
+// 360 degrees apart, the arc is the full circle.
+func BearingInRange(bearing, start, end float64) bool {
+	span := math.Mod(end-start+720.0, 360.0)
+	if span == 0.0 { return true }
+	return math.Mod(bearing-start+720.0, 360.0) <= span
+}
@@ -5,6 +5,8 @@ import(
 	"math"
 	"regexp"
 	"strconv"
+
+	"github.com/skypies/geo/altitude"
 )
 
 type Latlong struct {
@@ -34,9 +36,16 @@ func NewLatlong(in string) Latlong {
 }
 
 func parseCoord(in string) float64 {
+	coord,_ := parseCoordOK(in)
+	return coord
+}
+
+// parseCoordOK is parseCoord, but says whether the input was understood; so that 0 (the
+// equator, or the prime meridian) can be told apart from a failure.
+func parseCoordOK(in string) (float64, bool) {
 	decimalRe   := regexp.MustCompile(`^-?\d{1,3}\.\d{3,9}$`)
 	concatRe    := regexp.MustCompile(`^(\d{1,3})(\d\d)(\d\d(?:\.\d+))([NEWS])$`)
-	degMinSecRe := regexp.MustCompile(`^(\d{1,3})[°'\"\s]+(\d{2})[°'\"\s]+(\d{2}(?:\.\d+)?)[°'\"\s]+([NEWS])$`)
+	degMinSecRe := regexp.MustCompile(`^(\d{1,3})[-°'\"\s]+(\d{2})[-°'\"\s]+(\d{2}(?:\.\d+)?)[°'\"\s]*([NEWS])$`)
 
 	// Given degrees, minutes, seconds, and a compass dir; return a decimal coord
 	dms2dec := func(strs []string) float64 {
@@ -50,13 +59,13 @@ func parseCoord(in string) float64 {
 
 	if decimalRe.MatchString(in) {
 		coord,_ := strconv.ParseFloat(in, 64) // no errors ;)
-		return coord
+		return coord, true
 	} else if match := degMinSecRe.FindStringSubmatch(in); match != nil {//&& len(match) == 5 {
-		return dms2dec(match[1:])
+		return dms2dec(match[1:]), true
 	} else if match := concatRe.FindStringSubmatch(in); match != nil && len(match) == 5 {
-		return dms2dec(match[1:])
+		return dms2dec(match[1:]), true
 	}
-	return 0
+	return 0, false
 }
 
 type LatlongSlice []Latlong
@@ -81,6 +90,16 @@ func (ll Latlong)IsNil() bool {
 	return math.Abs(ll.Lat)<0.01 && math.Abs(ll.Long)<0.01
 }
 
+// HAEToMSL converts a GNSS altitude (feet above the ellipsoid) at this position into feet
+// above mean sea level, using the built-in geoid grid; the bool is false if the grid doesn't
+// cover the position (see altitude.HAEToMSL).
+func (ll Latlong)HAEToMSL(haeFeet float64) (float64, bool) {
+	return altitude.HAEToMSL(haeFeet, ll.Lat, ll.Long)
+}
+func (ll Latlong)MSLToHAE(mslFeet float64) (float64, bool) {
+	return altitude.MSLToHAE(mslFeet, ll.Lat, ll.Long)
+}
+
 // This probably isn't what you want
 func (from Latlong)ExactlyEqual(to Latlong) bool {
 	return from.Lat == to.Lat && from.Long == to.Long
@@ -116,7 +135,7 @@ func (from Latlong)MoveKM(heading, distanceKM float64) Latlong {
 	return Latlong{Lat:lat, Long:long}
 }
 func (from Latlong)MoveNM(heading, distanceNM float64) Latlong {
-	return from.MoveKM(heading, distanceNM * KNauticalMilePerKM)
+	return from.MoveKM(heading, NM2KM(distanceNM))
 }
 	
 func (at Latlong)MapsUrl() string {
@@ -18,6 +18,9 @@ func TestNewLatlong(t *testing.T) {
 
 		{"265702.96N,    1115709.62W",     Latlong{26.9508222, -111.9526722}},
 		{"465702.96S,    1315709.62E",      Latlong{-46.95082222, 131.95267222}},
+
+		{"37-10-35.680N / 122-00-29.950W",  Latlong{37.1765778, -122.0083194}},
+		{"37-37-08.6080N 122-22-29.6000W",  Latlong{37.6190578, -122.3748889}},
 	}
 
 	for i,test := range tests {
@@ -27,3 +30,16 @@ func TestNewLatlong(t *testing.T) {
 		}
 	}
 }
+
+func TestMoveNM(t *testing.T) {
+	from := Latlong{37,-122}
+	for _,nm := range []float64{1, 12, 100} {
+		to := from.MoveNM(90, nm)
+		if d := from.DistNM(to); d < nm*0.999 || d > nm*1.001 {
+			t.Errorf("MoveNM(%.0f): ended up %.3fNM away", nm, d)
+		}
+		if d := from.DistKM(to); d < NM2KM(nm)*0.999 || d > NM2KM(nm)*1.001 {
+			t.Errorf("MoveNM(%.0f): ended up %.3fKM away, expected %.3f", nm, d, NM2KM(nm))
+		}
+	}
+}
@@ -77,6 +77,19 @@ func (box LatlongBox)Contains(pos Latlong) bool {
 	return true
 }
 
+// NearestBoundaryPoint returns the point on the edge of the box that is closest to pos.
+func (box LatlongBox)NearestBoundaryPoint(pos Latlong) Latlong {
+	return closestOnLines(box.ToLines(), pos)
+}
+
+// SignedDistanceKM is the distance from pos to the nearest edge of the box; it is negative if
+// pos lies inside the box.
+func (box LatlongBox)SignedDistanceKM(pos Latlong) float64 {
+	dist := pos.DistKM(box.NearestBoundaryPoint(pos))
+	if box.Contains(pos) { return -1 * dist }
+	return dist
+}
+
 // Enclose increases the sixe of the box to include the point, if it doesn't fit
 func (box *LatlongBox)Enclose(pos Latlong) {
 	if (pos.Long < box.SW.Long) { box.SW.Long = pos.Long }
@@ -133,23 +146,15 @@ func (box LatlongBox)OverlapsLine(l LatlongLine) OverlapOutcome {
 	// Trivial bounding box test; discard if the line (as a box) has no overlap
 	if !box.IntersectsBox(l.Box()) { return Disjoint }
 
-	// If either endpoint is in the box, we're containing or straddling.
-	sInside,eInside := box.Contains(l.From), box.Contains(l.To)
-
-	// r2 is the line. If any of it is inside, figure out the line's relation to the box
-	if sInside && eInside { return OverlapR2IsContained }
-	if sInside            { return OverlapR2StraddlesEnd }
-	if eInside            { return OverlapR2StraddlesStart }
-	
-	// Else: we know the boxes overlap, but both line points are outside of it; if the line
-	// has a (bounded) intersection with any edge of the box, then we deem the box to be
-	// contained by the line.
-	if _,isect := box.BottomSide().Intersects(l); isect { return OverlapR2Contains }
-	if _,isect := box.LeftSide().Intersects(l); isect { return OverlapR2Contains }
-	if _,isect := box.RightSide().Intersects(l); isect { return OverlapR2Contains }
-	if _,isect := box.TopSide().Intersects(l); isect { return OverlapR2Contains }
-	
-	return Disjoint
+	// If both line points are outside of the box, but the line has a (bounded) intersection
+	// with any edge of the box, then we deem the box to be contained by the line.
+	crosses := func(l LatlongLine) bool {
+		for _,side := range []LatlongLine{box.BottomSide(), box.LeftSide(), box.RightSide(), box.TopSide()} {
+			if _,isect := side.Intersects(l); isect { return true }
+		}
+		return false
+	}
+	return lineOverlap(l, box.Contains, crosses)
 }
 
 
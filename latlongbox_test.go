@@ -2,6 +2,7 @@ package geo
 // go test -v github.com/skypies/geo
 
 import(
+	"math"
 	"testing"
 )
 
@@ -34,3 +35,27 @@ func TestBoxOverlapsLine(t *testing.T) {
 		}
 	}
 }
+
+func TestBoxSignedDistance(t *testing.T) {
+	box := Latlong{0,0}.BoxTo(Latlong{1,1})
+
+	tests := []struct{
+		Pos, Nearest Latlong
+		DistKM       float64 // Roughly 111KM per degree at the equator
+	}{
+		{Latlong{ 0.1, 0.5}, Latlong{0.0, 0.5}, -11.1},
+		{Latlong{-0.1, 0.5}, Latlong{0.0, 0.5},  11.1},
+		{Latlong{ 0.5, 1.5}, Latlong{0.5, 1.0},  55.6},
+		{Latlong{-1.0,-1.0}, Latlong{0.0, 0.0}, 157.2},
+	}
+
+	for i,test := range tests {
+		nearest := box.NearestBoundaryPoint(test.Pos)
+		if !nearest.Equal(test.Nearest) {
+			t.Errorf("[test % 2d] NearestBoundaryPoint: expected %s, got %s", i, test.Nearest, nearest)
+		}
+		if dist := box.SignedDistanceKM(test.Pos); math.Abs(dist - test.DistKM) > 0.1 {
+			t.Errorf("[test % 2d] SignedDistanceKM: expected %.1f, got %.1f", i, test.DistKM, dist)
+		}
+	}
+}
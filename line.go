@@ -133,6 +133,39 @@ func (line LatlongLine)ClosestDistance(pos Latlong) float64 {
 	return pos.Dist(line.ClosestTo(pos))
 }
 
+// }}}
+// {{{ l.ClosestToSegment
+
+// Like ClosestTo, but treats the line as bounded by From and To; if the perpendicular would land
+// off either end, that endpoint is returned instead. Longitudes are scaled by cos(lat), so this
+// works away from the equator, and for horizontal and vertical lines.
+func (line LatlongLine)ClosestToSegment(pos Latlong) Latlong {
+	scale := math.Cos(line.From.Lat * (math.Pi / 180.0))
+	dx,dy := (line.To.Long - line.From.Long) * scale, line.To.Lat - line.From.Lat
+	px,py := (pos.Long - line.From.Long) * scale, pos.Lat - line.From.Lat
+
+	lenSq := dx*dx + dy*dy
+	if lenSq == 0.0 { return line.From } // Degenerate line; it's just a point
+
+	ratio := (px*dx + py*dy) / lenSq
+	if ratio < 0.0 { ratio = 0.0 }
+	if ratio > 1.0 { ratio = 1.0 }
+
+	return line.From.InterpolateTo(line.To, ratio)
+}
+
+// closestOnLines returns the point, from any of the bounded lines, that is closest to pos.
+func closestOnLines(lines []LatlongLine, pos Latlong) Latlong {
+	closest,closestDist := Latlong{}, math.MaxFloat64
+	for _,line := range lines {
+		candidate := line.ClosestToSegment(pos)
+		if dist := pos.DistKM(candidate); dist < closestDist {
+			closest,closestDist = candidate, dist
+		}
+	}
+	return closest
+}
+
 // }}}
 // {{{ l.DistAlongLine
 
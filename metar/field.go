@@ -0,0 +1,110 @@
+package metar
+
+import(
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/skypies/geo"
+)
+
+// SpatialMethod says how a PressureField combines the settings from several stations.
+type SpatialMethod int
+const(
+	InverseDistance SpatialMethod = iota // Weighted by 1/distance^Power
+	PlanarFit                            // A least-squares plane through the stations (needs 3+)
+)
+
+// PressureField interpolates the altimeter setting at any position and time, from the reports
+// held in a Store; e.g. for a flight over the peninsula, between KSFO, KSJC and KOAK:
+//   pf := metar.NewPressureField(metar.NewStore(sfo.KAirports))
+// Each station's setting is first interpolated in time (see Store.StationInchesHg), and the
+// stations are then combined by position. It implements geo.AltimeterSource.
+type PressureField struct {
+	Store      *Store
+	Method     SpatialMethod
+	Power      float64 // For InverseDistance
+	MaxDistKM  float64 // Ignore stations further away than this; zero means no limit
+}
+
+func NewPressureField(s *Store) PressureField {
+	return PressureField{Store:s, Method:InverseDistance, Power:2.0}
+}
+
+func (pf PressureField)String() string {
+	method := "IDW"
+	if pf.Method == PlanarFit { method = "planar" }
+	return fmt.Sprintf("pressure field (%s) over %s", method, pf.Store)
+}
+
+type stationValue struct {
+	Station  string
+	X,Y      float64 // KM east & north of the position of interest
+	DistKM   float64
+	InchesHg float64
+}
+
+// values returns the stations with data for the time, relative to pos, sorted by distance.
+func (pf PressureField)values(pos geo.Latlong, t time.Time) []stationValue {
+	ret := []stationValue{}
+	for station,loc := range pf.Store.Stations {
+		d := pos.DistKM(loc)
+		if pf.MaxDistKM > 0 && d > pf.MaxDistKM { continue }
+		v,err := pf.Store.StationInchesHg(station, t)
+		if err != nil { continue }
+		b := pos.BearingTowards(loc) * math.Pi / 180.0
+		ret = append(ret, stationValue{station, d*math.Sin(b), d*math.Cos(b), d, v})
+	}
+	sort.Slice(ret, func(i,j int) bool { return ret[i].DistKM < ret[j].DistKM })
+	return ret
+}
+
+func inverseDistance(vals []stationValue, power float64) float64 {
+	if vals[0].DistKM < 0.01 { return vals[0].InchesHg } // On top of a station
+
+	sum,sumWeights := 0.0, 0.0
+	for _,v := range vals {
+		w := 1.0 / math.Pow(v.DistKM, power)
+		sum += w * v.InchesHg
+		sumWeights += w
+	}
+	return sum / sumWeights
+}
+
+// planarFit fits v = a + b.x + c.y by least squares; as the position of interest is at the
+// origin, the answer is a. The bool is false if the stations are (nearly) collinear.
+func planarFit(vals []stationValue) (float64, bool) {
+	if len(vals) < 3 { return 0, false }
+
+	var n, sx, sy, sxx, sxy, syy, sv, sxv, syv float64
+	for _,v := range vals {
+		n++
+		sx += v.X; sy += v.Y
+		sxx += v.X*v.X; sxy += v.X*v.Y; syy += v.Y*v.Y
+		sv += v.InchesHg; sxv += v.X*v.InchesHg; syv += v.Y*v.InchesHg
+	}
+
+	// Cramer's rule on the normal equations
+	det3 := func(a,b,c, d,e,f, g,h,i float64) float64 {
+		return a*(e*i - f*h) - b*(d*i - f*g) + c*(d*h - e*g)
+	}
+	det := det3(n,sx,sy, sx,sxx,sxy, sy,sxy,syy)
+	if math.Abs(det) < 1e-6 * math.Pow(sxx+syy, 2) { return 0, false }
+
+	return det3(sv,sx,sy, sxv,sxx,sxy, syv,sxy,syy) / det, true
+}
+
+// InchesHg implements geo.AltimeterSource. PlanarFit falls back to InverseDistance if there are
+// too few stations, or they lie in a line.
+func (pf PressureField)InchesHg(pos geo.Latlong, t time.Time) (float64, error) {
+	vals := pf.values(pos, t)
+	if len(vals) == 0 {
+		return 0, fmt.Errorf("pressure field: no stations with data near %s at %s", pos, t)
+	}
+
+	if pf.Method == PlanarFit {
+		if v,ok := planarFit(vals); ok { return v, nil }
+	}
+	return inverseDistance(vals, pf.Power), nil
+}
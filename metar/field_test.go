@@ -0,0 +1,69 @@
+package metar
+
+import(
+	"math"
+	"testing"
+	"time"
+
+	"github.com/skypies/geo"
+)
+
+func TestPressureField(t *testing.T) {
+	at := func(h,m int) time.Time { return time.Date(2016, 3, 12, h, m, 0, 0, time.UTC) }
+
+	s := NewStore(testStations)
+	for _,r := range []Report{
+		{Station:"KSFO", Time:at(17,56), InchesHg:29.90, HasAltimeter:true},
+		{Station:"KSFO", Time:at(18,56), InchesHg:29.96, HasAltimeter:true},
+		{Station:"KSJC", Time:at(17,53), InchesHg:30.02, HasAltimeter:true},
+		{Station:"KOAK", Time:at(17,53), InchesHg:29.93, HasAltimeter:true},
+	} {
+		s.Add(r)
+	}
+
+	idw := NewPressureField(s)
+	planar := idw
+	planar.Method = PlanarFit
+	var _ geo.AltimeterSource = idw
+
+	sfo,sjc,oak := testStations["KSFO"], testStations["KSJC"], testStations["KOAK"]
+	midpoint := func(a,b geo.Latlong) geo.Latlong { return geo.Latlong{(a.Lat+b.Lat)/2, (a.Long+b.Long)/2} }
+
+	tests := []struct{
+		Field    PressureField
+		Pos      geo.Latlong
+		T        time.Time
+		Expected float64
+	}{
+		{idw,    sfo, at(17,56), 29.90},  // At a station, we get its value
+		{idw,    sfo, at(18,26), 29.93},  // ... interpolated in time
+		{planar, sjc, at(17,56), 30.02},  // A plane through three points hits all of them
+		{planar, oak, at(17,56), 29.93},
+		{planar, midpoint(sfo,sjc), at(17,56), 29.96},  // Planes are linear between stations
+	}
+	for i,test := range tests {
+		actual,err := test.Field.InchesHg(test.Pos, test.T)
+		if err != nil || math.Abs(actual - test.Expected) > 0.001 {
+			t.Errorf("[%d] %s: expected %.3f, saw %.4f (%v)", i, test.Pos, test.Expected, actual, err)
+		}
+	}
+
+	// Between SFO and SJC, IDW should land between the two (pulled a little towards OAK)
+	mid := midpoint(sfo, sjc)
+	if v,_ := idw.InchesHg(mid, at(17,56)); v <= 29.90 || v >= 30.02 {
+		t.Errorf("IDW midpoint: saw %.3f", v)
+	}
+
+	// With only two stations in range (SJC is ~45KM away), the planar fit falls back to IDW
+	nearPlanar,nearIDW := planar,idw
+	nearPlanar.MaxDistKM, nearIDW.MaxDistKM = 40, 40
+	a,_ := nearPlanar.InchesHg(sfo.MoveKM(0,1), at(17,56))
+	b,_ := nearIDW.InchesHg(sfo.MoveKM(0,1), at(17,56))
+	if a != b || math.Abs(a - 29.90) > 0.01 {
+		t.Errorf("fallback: saw %.3f, IDW %.3f", a, b)
+	}
+
+	if _,err := idw.InchesHg(sfo, at(6,0)); err == nil {
+		t.Errorf("expected an error, with no reports near the time")
+	}
+}
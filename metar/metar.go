@@ -0,0 +1,135 @@
+// Package metar parses METAR and SPECI weather reports, for the altimeter settings (and other
+// bits) needed to correct pressure altitudes.
+package metar
+
+import(
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skypies/geo/altitude"
+)
+
+const KnotsPerMPS = 1.94384
+
+// Report is a single decoded METAR or SPECI. Anything after RMK is ignored.
+type Report struct {
+	Type             string     // "METAR" or "SPECI"
+	Station          string     // ICAO identifier, e.g. "KSFO"
+	Time             time.Time  // Observation time (UTC)
+
+	InchesHg         float64    // Altimeter setting; Q groups (hPa) are converted
+	HasAltimeter     bool
+
+	TempC,DewpointC  float64
+	HasTemp          bool
+
+	WindDirection    int        // Degrees true; -1 for variable
+	WindSpeed        float64    // Knots
+	WindGust         float64    // Knots; zero if no gusts
+	HasWind          bool
+
+	Raw              string
+}
+
+func (r Report)String() string {
+	str := fmt.Sprintf("%s %s %s", r.Type, r.Station, r.Time.Format("2006/01/02 15:04Z"))
+	if r.HasWind { str += fmt.Sprintf(" wind %03d@%.0fkt", r.WindDirection, r.WindSpeed) }
+	if r.HasTemp { str += fmt.Sprintf(" %.0f/%.0fC", r.TempC, r.DewpointC) }
+	if r.HasAltimeter { str += fmt.Sprintf(" %.2finHg", r.InchesHg) }
+	return str
+}
+
+var(
+	stationRe   = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	timeRe      = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	windRe      = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS)$`)
+	tempRe      = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	altimeterRe = regexp.MustCompile(`^([AQ])(\d{4})$`)
+)
+
+func parseTemp(s string) float64 {
+	neg := strings.HasPrefix(s, "M")
+	v,_ := strconv.ParseFloat(strings.TrimPrefix(s, "M"), 64)
+	if neg { v *= -1 }
+	return v
+}
+
+// reportTime works out the full time of an observation; reports only carry the day of the
+// month, so we take the month and year from ref (e.g. the time the file was archived). If the
+// day is later than ref's, the report must be from the previous month; it is an error if that
+// month has no such day.
+func reportTime(ref time.Time, day, hour, min int) (time.Time, error) {
+	ref = ref.UTC()
+	if day < 1 || hour > 23 || min > 59 {
+		return time.Time{}, fmt.Errorf("metar: bad time %02d%02d%02dZ", day, hour, min)
+	}
+	if day <= ref.Day() {
+		return time.Date(ref.Year(), ref.Month(), day, hour, min, 0, 0, time.UTC), nil
+	}
+
+	// Day zero of this month is the last day of the previous one
+	if prev := time.Date(ref.Year(), ref.Month(), 0, 0, 0, 0, 0, time.UTC); day > prev.Day() {
+		return time.Time{}, fmt.Errorf("metar: %s has no day %d", prev.Format("Jan 2006"), day)
+	}
+	return time.Date(ref.Year(), ref.Month()-1, day, hour, min, 0, 0, time.UTC), nil
+}
+
+// Parse decodes a single report. The station and time are required; the other fields are
+// optional, and have a Has* flag to say if they were found.
+func Parse(text string, ref time.Time) (Report, error) {
+	r := Report{Type:"METAR", Raw:strings.TrimSpace(text)}
+	fields := strings.Fields(strings.TrimSuffix(r.Raw, "="))
+
+	if len(fields) > 0 && (fields[0] == "METAR" || fields[0] == "SPECI") {
+		r.Type,fields = fields[0], fields[1:]
+	}
+	if len(fields) < 2 || !stationRe.MatchString(fields[0]) {
+		return r, fmt.Errorf("metar: no station in '%s'", r.Raw)
+	}
+	r.Station = fields[0]
+
+	m := timeRe.FindStringSubmatch(fields[1])
+	if m == nil { return r, fmt.Errorf("metar: no time in '%s'", r.Raw) }
+	day,_ := strconv.Atoi(m[1])
+	hour,_ := strconv.Atoi(m[2])
+	min,_ := strconv.Atoi(m[3])
+	t,err := reportTime(ref, day, hour, min)
+	if err != nil { return r, fmt.Errorf("%v, in '%s'", err, r.Raw) }
+	r.Time = t
+
+	for _,f := range fields[2:] {
+		if f == "RMK" { break }
+
+		if m := windRe.FindStringSubmatch(f); m != nil && !r.HasWind {
+			r.HasWind = true
+			r.WindDirection = -1
+			if m[1] != "VRB" { r.WindDirection,_ = strconv.Atoi(m[1]) }
+			r.WindSpeed,_ = strconv.ParseFloat(m[2], 64)
+			if m[3] != "" { r.WindGust,_ = strconv.ParseFloat(m[3], 64) }
+			if m[4] == "MPS" {
+				r.WindSpeed *= KnotsPerMPS
+				r.WindGust *= KnotsPerMPS
+			}
+
+		} else if m := tempRe.FindStringSubmatch(f); m != nil && !r.HasTemp {
+			r.HasTemp = true
+			r.TempC = parseTemp(m[1])
+			r.DewpointC = r.TempC
+			if m[2] != "" { r.DewpointC = parseTemp(m[2]) }
+
+		} else if m := altimeterRe.FindStringSubmatch(f); m != nil && !r.HasAltimeter {
+			v,_ := strconv.ParseFloat(m[2], 64)
+			r.HasAltimeter = true
+			if m[1] == "A" {
+				r.InchesHg = v / 100.0
+			} else {
+				r.InchesHg = altitude.HPaToInchesHg(v)
+			}
+		}
+	}
+
+	return r, nil
+}
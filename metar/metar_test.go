@@ -0,0 +1,79 @@
+package metar
+
+import(
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	ref := time.Date(2016, time.March, 12, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct{
+		Text     string
+		Expected Report
+	}{
+		{"METAR KSFO 121756Z 29012G20KT 10SM FEW008 BKN200 18/12 A2992 RMK AO2 SLP132 T01780122",
+			Report{Type:"METAR", Station:"KSFO", Time:time.Date(2016,3,12,17,56,0,0,time.UTC),
+				InchesHg:29.92, HasAltimeter:true, TempC:18, DewpointC:12, HasTemp:true,
+				WindDirection:290, WindSpeed:12, WindGust:20, HasWind:true}},
+		{"SPECI KOAK 121812Z AUTO VRB03KT 2SM BR OVC004 M02/M05 A3012",
+			Report{Type:"SPECI", Station:"KOAK", Time:time.Date(2016,3,12,18,12,0,0,time.UTC),
+				InchesHg:30.12, HasAltimeter:true, TempC:-2, DewpointC:-5, HasTemp:true,
+				WindDirection:-1, WindSpeed:3, HasWind:true}},
+		{"EGLL 281650Z 24005MPS 9999 SCT030 12/ Q1013=", // From last month
+			Report{Type:"METAR", Station:"EGLL", Time:time.Date(2016,2,28,16,50,0,0,time.UTC),
+				InchesHg:29.91, HasAltimeter:true, TempC:12, DewpointC:12, HasTemp:true,
+				WindDirection:240, WindSpeed:9.7, HasWind:true}},
+		{"KSJC 121753Z 00000KT 10SM CLR RMK A2992",
+			Report{Type:"METAR", Station:"KSJC", Time:time.Date(2016,3,12,17,53,0,0,time.UTC),
+				HasWind:true}},
+	}
+
+	for i,test := range tests {
+		r,err := Parse(test.Text, ref)
+		if err != nil { t.Errorf("[%d] error: %v", i, err); continue }
+
+		e := test.Expected
+		if r.Type != e.Type || r.Station != e.Station || !r.Time.Equal(e.Time) ||
+			r.HasAltimeter != e.HasAltimeter || math.Abs(r.InchesHg - e.InchesHg) > 0.005 ||
+			r.HasTemp != e.HasTemp || r.TempC != e.TempC || r.DewpointC != e.DewpointC ||
+			r.HasWind != e.HasWind || r.WindDirection != e.WindDirection ||
+			math.Abs(r.WindSpeed - e.WindSpeed) > 0.05 || r.WindGust != e.WindGust {
+			t.Errorf("[%d] mismatch:-\n expected: %s\n saw     : %s", i, e, r)
+		}
+	}
+
+	for i,bad := range []string{"", "METAR", "KSFO A2992", "ksfo 121756Z A2992", "KSFO 122556Z A2992"} {
+		if _,err := Parse(bad, ref); err == nil {
+			t.Errorf("bad[%d] '%s': expected an error", i, bad)
+		}
+	}
+}
+
+func TestReportTime(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 12, 0, 0, 0, time.UTC) }
+	tests := []struct{
+		Ref       time.Time
+		Day       int
+		Expected  time.Time // zero if the day can't be valid
+	}{
+		{date(2016, 3, 12), 12, date(2016, 3, 12)},
+		{date(2016, 3, 12), 28, date(2016, 2, 28)},
+		{date(2016, 3,  1), 29, date(2016, 2, 29)}, // Leap year
+		{date(2016, 3,  1), 30, time.Time{}},
+		{date(2015, 3,  1), 29, time.Time{}},
+		{date(2016, 3,  1), 31, time.Time{}},
+		{date(2016, 5,  1), 31, time.Time{}},       // April is short
+		{date(2016, 1,  1), 31, date(2015,12, 31)},
+		{date(2016, 1,  1),  0, time.Time{}},
+	}
+	for i,test := range tests {
+		actual,err := reportTime(test.Ref, test.Day, 12, 0)
+		if test.Expected.IsZero() {
+			if err == nil { t.Errorf("[%d] day %d, ref %s: expected an error, saw %s", i, test.Day, test.Ref, actual) }
+		} else if err != nil || !actual.Equal(test.Expected) {
+			t.Errorf("[%d] day %d, ref %s: expected %s, saw %s (%v)", i, test.Day, test.Ref, test.Expected, actual, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package metar
+
+import(
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/skypies/geo"
+)
+
+// Store holds reports for a set of stations, indexed by time. It implements geo.AltimeterSource.
+// Load it up before use; it is not safe to Add while other goroutines are reading.
+type Store struct {
+	Stations  map[string]geo.Latlong  // Where the stations are; needed for lookups by position
+	MaxGap    time.Duration           // Ignore reports further than this from the time of interest
+	reports   map[string][]Report     // Sorted by time; only those with an altimeter setting
+}
+
+func NewStore(stations map[string]geo.Latlong) *Store {
+	return &Store{
+		Stations: stations,
+		MaxGap: 3 * time.Hour,
+		reports: map[string][]Report{},
+	}
+}
+
+func (s *Store)String() string { return fmt.Sprintf("METAR store (%d stations)", len(s.reports)) }
+
+// Add stores the report, if it has an altimeter setting.
+func (s *Store)Add(r Report) {
+	if !r.HasAltimeter { return }
+	rs := s.reports[r.Station]
+	i := sort.Search(len(rs), func(i int) bool { return !rs[i].Time.Before(r.Time) })
+	if i < len(rs) && rs[i].Time.Equal(r.Time) {
+		rs[i] = r // A correction, or a duplicate
+	} else {
+		rs = append(rs, Report{})
+		copy(rs[i+1:], rs[i:])
+		rs[i] = r
+	}
+	s.reports[r.Station] = rs
+}
+
+// NOAA's archives put a date line ("2016/03/12 17:56") in front of each report
+var dateLineRe = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}$`)
+
+// LoadErrors lists the lines that Load could not parse.
+type LoadErrors []error
+
+func (le LoadErrors)Error() string {
+	if len(le) == 1 { return le[0].Error() }
+	return fmt.Sprintf("%d bad lines; first: %v", len(le), le[0])
+}
+
+// Load reads archived reports, one per line. The full date of each report is taken from the
+// most recent date line, if there is one, or otherwise from ref. Archives contain the odd bad
+// line; these are skipped, and returned as LoadErrors once all the good lines are loaded.
+func (s *Store)Load(rdr io.Reader, ref time.Time) error {
+	bad := LoadErrors{}
+	scanner := bufio.NewScanner(rdr)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if len(line) == 0 { continue }
+
+		if dateLineRe.MatchString(line) {
+			t,err := time.Parse("2006/01/02 15:04", line)
+			if err != nil { bad = append(bad, fmt.Errorf("metar line %d: %v", lineNum, err)); continue }
+			ref = t
+			continue
+		}
+
+		r,err := Parse(line, ref)
+		if err != nil { bad = append(bad, fmt.Errorf("metar line %d: %v", lineNum, err)); continue }
+		s.Add(r)
+	}
+	if err := scanner.Err(); err != nil { return err }
+	if len(bad) > 0 { return bad }
+	return nil
+}
+
+func (s *Store)LoadFile(filename string, ref time.Time) error {
+	f,err := os.Open(filename)
+	if err != nil { return err }
+	defer f.Close()
+	if err := s.Load(f, ref); err != nil { return fmt.Errorf("%s: %w", filename, err) }
+	return nil
+}
+
+// bracket returns the last report at or before t, and the first one after it.
+func (s *Store)bracket(station string, t time.Time) (prev,next *Report) {
+	rs := s.reports[station]
+	i := sort.Search(len(rs), func(i int) bool { return rs[i].Time.After(t) })
+	if i > 0 && t.Sub(rs[i-1].Time) <= s.MaxGap { prev = &rs[i-1] }
+	if i < len(rs) && rs[i].Time.Sub(t) <= s.MaxGap { next = &rs[i] }
+	return
+}
+
+// Nearest returns the report from the station closest in time to t.
+func (s *Store)Nearest(station string, t time.Time) (Report, error) {
+	prev,next := s.bracket(station, t)
+	switch {
+	case prev == nil && next == nil:
+		return Report{}, fmt.Errorf("metar: no report for %s within %s of %s", station, s.MaxGap, t)
+	case next == nil:                          return *prev, nil
+	case prev == nil:                          return *next, nil
+	case t.Sub(prev.Time) <= next.Time.Sub(t): return *prev, nil
+	default:                                   return *next, nil
+	}
+}
+
+// StationInchesHg interpolates linearly in time between the reports either side of t; if there
+// is only one, its value is used.
+func (s *Store)StationInchesHg(station string, t time.Time) (float64, error) {
+	prev,next := s.bracket(station, t)
+	if prev == nil || next == nil {
+		r,err := s.Nearest(station, t)
+		return r.InchesHg, err
+	}
+
+	span := next.Time.Sub(prev.Time).Seconds()
+	if span == 0 { return prev.InchesHg, nil }
+	f := t.Sub(prev.Time).Seconds() / span
+	return prev.InchesHg + f*(next.InchesHg - prev.InchesHg), nil
+}
+
+// NearestStation returns the closest station to pos that has a report near the time.
+func (s *Store)NearestStation(pos geo.Latlong, t time.Time) (string, error) {
+	best,bestDist := "", math.Inf(1)
+	for station,loc := range s.Stations {
+		if prev,next := s.bracket(station, t); prev == nil && next == nil { continue }
+		if d := pos.DistKM(loc); d < bestDist || (d == bestDist && station < best) {
+			best,bestDist = station, d
+		}
+	}
+	if best == "" { return "", fmt.Errorf("metar: no stations with reports near %s", t) }
+	return best, nil
+}
+
+// InchesHg implements geo.AltimeterSource, using the nearest station with data.
+func (s *Store)InchesHg(pos geo.Latlong, t time.Time) (float64, error) {
+	station,err := s.NearestStation(pos, t)
+	if err != nil { return 0, err }
+	return s.StationInchesHg(station, t)
+}
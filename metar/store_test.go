@@ -0,0 +1,86 @@
+package metar
+
+import(
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/skypies/geo"
+)
+
+var testArchive = `
+2016/03/12 17:56
+KSFO 121756Z 29012KT 10SM 18/12 A2990
+2016/03/12 18:56
+KSFO 121856Z 29012KT 10SM 18/12 A2996
+2016/03/12 17:53
+KSJC 121753Z 00000KT 10SM 20/10 A3002
+KSJC 121653Z 00000KT 10SM 19/10 RMK NO ALTIMETER
+`
+
+var testStations = map[string]geo.Latlong{
+	"KSFO": geo.Latlong{37.6188172, -122.3754281},
+	"KSJC": geo.Latlong{37.3639472, -121.9289375},
+	"KOAK": geo.Latlong{37.7212597, -122.2211489},
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore(testStations)
+	if err := s.Load(strings.NewReader(testArchive), time.Now()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var _ geo.AltimeterSource = s
+
+	at := func(h,m int) time.Time { return time.Date(2016, 3, 12, h, m, 0, 0, time.UTC) }
+
+	tests := []struct{
+		Station   string
+		T         time.Time
+		Expected  float64
+		ExpectErr bool
+	}{
+		{"KSFO", at(17,56), 29.90, false},
+		{"KSFO", at(18,26), 29.93, false}, // Halfway
+		{"KSFO", at(16,00), 29.90, false}, // Before the first, but within MaxGap
+		{"KSFO", at(21,00), 29.96, false},
+		{"KSFO", at(12,00),     0, true},  // Too long before
+		{"KSJC", at(12,00),     0, true},  // The earlier report had no altimeter
+		{"KOAK", at(18,00),     0, true},
+	}
+	for i,test := range tests {
+		actual,err := s.StationInchesHg(test.Station, test.T)
+		if test.ExpectErr {
+			if err == nil { t.Errorf("[%d] expected an error, saw %.2f", i, actual) }
+			continue
+		}
+		if err != nil || math.Abs(actual - test.Expected) > 1e-6 {
+			t.Errorf("[%d] %s@%s: expected %.2f, saw %.4f (%v)", i, test.Station, test.T,
+				test.Expected, actual, err)
+		}
+	}
+
+	if r,err := s.Nearest("KSFO", at(18,30)); err != nil || r.InchesHg != 29.96 {
+		t.Errorf("Nearest: expected 29.96, saw %s (%v)", r, err)
+	}
+
+	// KOAK has no data, so a position right next to it should use KSFO
+	nearOAK := testStations["KOAK"].MoveKM(0, 1)
+	if station,_ := s.NearestStation(nearOAK, at(18,00)); station != "KSFO" {
+		t.Errorf("NearestStation: expected KSFO, saw %s", station)
+	}
+	if inHg,err := s.InchesHg(testStations["KSJC"], at(18,00)); err != nil || inHg != 30.02 {
+		t.Errorf("InchesHg: expected 30.02, saw %.2f (%v)", inHg, err)
+	}
+
+	// Bad lines are skipped, and reported after the good ones are loaded
+	s = NewStore(testStations)
+	archive := "KSFO 121756Z 29012KT 10SM 18/12 A2990\nKSFO GARBLED\nKSFO 121856Z 29012KT 10SM 18/12 A2996\n"
+	err := s.Load(strings.NewReader(archive), at(20,00))
+	if le,ok := err.(LoadErrors); !ok || len(le) != 1 || !strings.Contains(le[0].Error(), "line 2") {
+		t.Errorf("Load: expected one bad line, saw %v", err)
+	}
+	if r,err := s.Nearest("KSFO", at(18,56)); err != nil || r.InchesHg != 29.96 {
+		t.Errorf("Load: the line after the bad one was not loaded: %s (%v)", r, err)
+	}
+}
@@ -0,0 +1,130 @@
+package geo
+
+// Readers for the FAA's NASR 28-day subscription files, from
+// https://www.faa.gov/air_traffic/flight_info/aeronav/aero_data/NASR_Subscription/. These are
+// fixed-width text files; the layouts are documented in FIX_RF.txt, NAV_RF.txt and APT_RF.txt,
+// which come with the data. Only the main record of each entry (FIX1, NAV1, APT) is read; the
+// others are skipped.
+
+import(
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// column is a field in a fixed-width record; Start is 1-based, as in the layout documents.
+type column struct { Start, Len int }
+
+func (c column)get(line string) string {
+	from,to := c.Start-1, c.Start-1+c.Len
+	if from >= len(line) { return "" }
+	if to > len(line) { to = len(line) }
+	return strings.TrimSpace(line[from:to])
+}
+
+var(
+	kNASRFixID      = column{5, 30}
+	kNASRFixLat     = column{67, 14}  // e.g. "37-10-35.680N"
+	kNASRFixLong    = column{81, 14}
+
+	kNASRNavID      = column{5, 4}
+	kNASRNavDate    = column{33, 10}  // MM/DD/YYYY
+	kNASRNavLat     = column{372, 14}
+	kNASRNavLong    = column{397, 14}
+
+	kNASRAptID      = column{28, 4}   // FAA location identifier, e.g. "SFO"
+	kNASRAptDate    = column{32, 10}
+	kNASRAptLat     = column{524, 15}
+	kNASRAptLong    = column{551, 15}
+	kNASRAptICAO    = column{1211, 7} // e.g. "KSFO"; often blank for small fields
+)
+
+const kNASRDateFormat = "01/02/2006"
+
+// parseNASR reads the records of the given type, and hands each one to f.
+func parseNASR(r io.Reader, source, recType string, f func(line string) (FixEntry, error)) ([]FixEntry, error) {
+	ret := []FixEntry{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), 1024*1024) // APT records are ~1500 chars
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if !strings.HasPrefix(line, recType) { continue }
+
+		fe,err := f(line)
+		if err != nil { return nil, fmt.Errorf("%s: line %d: %v", source, lineNum, err) }
+		fe.Source = source
+		ret = append(ret, fe)
+	}
+	if err := scanner.Err(); err != nil { return nil, fmt.Errorf("%s: %v", source, err) }
+	return ret, nil
+}
+
+func parseNASRPos(line string, latCol, longCol column) (Latlong, error) {
+	lat,long := latCol.get(line), longCol.get(line)
+	latVal,latOK := parseCoordOK(lat)
+	longVal,longOK := parseCoordOK(long)
+	if !latOK || !longOK { return Latlong{}, fmt.Errorf("bad position '%s' '%s'", lat, long) }
+	return Latlong{latVal, longVal}, nil
+}
+
+func parseNASRDate(line string, col column) (time.Time, error) {
+	if s := col.get(line); s != "" { return time.Parse(kNASRDateFormat, s) }
+	return time.Time{}, nil
+}
+
+// ParseNASRFix reads FIX.txt, returning an entry of type "waypoint" for each fix.
+func ParseNASRFix(r io.Reader, source string) ([]FixEntry, error) {
+	return parseNASR(r, source, "FIX1", func(line string) (FixEntry, error) {
+		pos,err := parseNASRPos(line, kNASRFixLat, kNASRFixLong)
+		fe := FixEntry{NamedLatlong:NamedLatlong{kNASRFixID.get(line), pos}, Type:"waypoint"}
+		return fe, err
+	})
+}
+
+// ParseNASRNav reads NAV.txt, returning an entry of type "navaid" for each VOR, NDB, etc.
+func ParseNASRNav(r io.Reader, source string) ([]FixEntry, error) {
+	return parseNASR(r, source, "NAV1", func(line string) (FixEntry, error) {
+		fe := FixEntry{NamedLatlong:NamedLatlong{Name:kNASRNavID.get(line)}, Type:"navaid"}
+		var err error
+		if fe.Latlong,err = parseNASRPos(line, kNASRNavLat, kNASRNavLong); err != nil { return fe, err }
+		fe.Effective,err = parseNASRDate(line, kNASRNavDate)
+		return fe, err
+	})
+}
+
+// ParseNASRAirport reads APT.txt, returning an entry of type "airport" for each landing facility.
+// Airports with an ICAO identifier are named by it (e.g. "KSFO"), with the FAA identifier as an
+// alias; others just have the FAA identifier (e.g. "O69").
+func ParseNASRAirport(r io.Reader, source string) ([]FixEntry, error) {
+	return parseNASR(r, source, "APT", func(line string) (FixEntry, error) {
+		fe := FixEntry{NamedLatlong:NamedLatlong{Name:kNASRAptID.get(line)}, Type:"airport"}
+		if icao := kNASRAptICAO.get(line); icao != "" && icao != fe.Name {
+			fe.Name,fe.Aliases = icao, []string{fe.Name}
+		}
+		var err error
+		if fe.Latlong,err = parseNASRPos(line, kNASRAptLat, kNASRAptLong); err != nil { return fe, err }
+		fe.Effective,err = parseNASRDate(line, kNASRAptDate)
+		return fe, err
+	})
+}
+
+// ReadNavDataFile reads a NASR file (picked by its name: FIX.txt, NAV.txt or APT.txt), or
+// otherwise an ARINC 424 file. The filename is the source of the entries.
+func ReadNavDataFile(filename string) ([]FixEntry, error) {
+	f,err := os.Open(filename)
+	if err != nil { return nil, err }
+	defer f.Close()
+
+	switch strings.ToUpper(filepath.Base(filename)) {
+	case "FIX.TXT": return ParseNASRFix(f, filename)
+	case "NAV.TXT": return ParseNASRNav(f, filename)
+	case "APT.TXT": return ParseNASRAirport(f, filename)
+	default:        return ParseARINC424(f, filename)
+	}
+}
@@ -0,0 +1,78 @@
+package geo
+
+import(
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedWidth builds a record, with each field starting at the given (1-based) column.
+func fixedWidth(fields map[int]string) string {
+	cols := []int{}
+	for col,_ := range fields { cols = append(cols, col) }
+	sort.Ints(cols)
+
+	line := ""
+	for _,col := range cols {
+		if len(line) < col-1 { line += strings.Repeat(" ", col-1-len(line)) }
+		line = line[:col-1] + fields[col]
+	}
+	return line
+}
+
+func TestParseNASR(t *testing.T) {
+	fixes := strings.Join([]string{
+		fixedWidth(map[int]string{1:"FIX1", 5:"EPICK", 35:"CALIFORNIA", 65:"K2", 67:"37-10-35.680N", 81:"122-00-29.950W", 95:"FIX"}),
+		fixedWidth(map[int]string{1:"FIX2", 5:"EPICK", 35:"CALIFORNIA", 65:"K2", 67:"SJC*C*131/27.6"}),
+		fixedWidth(map[int]string{1:"FIX1", 5:"MENLO", 35:"CALIFORNIA", 65:"K2", 67:"37-27-50.190N", 81:"122-09-12.900W", 95:"FIX"}),
+	}, "\n")
+	navs := fixedWidth(map[int]string{1:"NAV1", 5:"SJC", 9:"VOR/DME", 29:"SJC", 33:"01/07/2016", 43:"SAN JOSE",
+		372:"37-22-29.020N", 386:"134549.020N", 397:"121-56-40.800W", 411:"439000.800W"})
+	apts := strings.Join([]string{
+		fixedWidth(map[int]string{1:"APT", 4:"02187.*A", 15:"AIRPORT", 28:"SFO", 32:"03/31/2016", 134:"SAN FRANCISCO INTL",
+			524:"37-37-08.6080N", 551:"122-22-29.6000W", 1211:"KSFO"}),
+		fixedWidth(map[int]string{1:"RWY", 4:"02187.*A", 17:"10L/28R"}),
+		fixedWidth(map[int]string{1:"APT", 4:"02225.*A", 15:"AIRPORT", 28:"O69", 32:"03/31/2016", 134:"PETALUMA MUNI",
+			524:"38-15-29.7000N", 551:"122-36-20.3000W"}),
+	}, "\n")
+
+	fes,err := ParseNASRFix(strings.NewReader(fixes), "FIX.txt")
+	if err != nil { t.Fatal(err) }
+	if len(fes) != 2 { t.Fatalf("FIX: expected 2 fixes, saw %v", fes) }
+	if fes[0].Name != "EPICK" || fes[0].Type != "waypoint" || fes[0].Source != "FIX.txt" ||
+		!fes[0].Equal(Latlong{37.1765778, -122.0083194}) {
+		t.Errorf("FIX: saw %s", fes[0])
+	}
+
+	fes,err = ParseNASRNav(strings.NewReader(navs), "NAV.txt")
+	if err != nil { t.Fatal(err) }
+	if len(fes) != 1 || fes[0].Name != "SJC" || fes[0].Type != "navaid" ||
+		!fes[0].Equal(Latlong{37.3747278, -121.9446667}) ||
+		!fes[0].Effective.Equal(time.Date(2016,1,7,0,0,0,0,time.UTC)) {
+		t.Errorf("NAV: saw %v", fes)
+	}
+
+	fes,err = ParseNASRAirport(strings.NewReader(apts), "APT.txt")
+	if err != nil { t.Fatal(err) }
+	if len(fes) != 2 { t.Fatalf("APT: expected 2 airports, saw %v", fes) }
+	if fes[0].Name != "KSFO" || len(fes[0].Aliases) != 1 || fes[0].Aliases[0] != "SFO" ||
+		!fes[0].Equal(Latlong{37.6190578, -122.3748889}) {
+		t.Errorf("APT: saw %s", fes[0])
+	}
+	if fes[1].Name != "O69" || len(fes[1].Aliases) != 0 {
+		t.Errorf("APT: saw %s", fes[1])
+	}
+
+	// On the equator
+	zero := fixedWidth(map[int]string{1:"FIX1", 5:"NULIS", 67:"00-00-00.000N", 81:"000-30-00.000E"})
+	if fes,err := ParseNASRFix(strings.NewReader(zero), "FIX.txt"); err != nil || len(fes) != 1 ||
+		fes[0].Lat != 0 || fes[0].Long != 0.5 {
+		t.Errorf("FIX: on the equator, saw %v, %v", fes, err)
+	}
+
+	bad := fixedWidth(map[int]string{1:"FIX1", 5:"BADDY", 67:"37-10-35.680X", 81:"122-00-29.950W"})
+	if _,err := ParseNASRFix(strings.NewReader(bad), "FIX.txt"); err == nil {
+		t.Errorf("FIX: expected an error for a bad latitude")
+	}
+}
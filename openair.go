@@ -0,0 +1,285 @@
+package geo
+
+// Parser for the OpenAir airspace format, as documented at
+// http://www.winpilot.com/usersguide/userairspace.asp
+
+import(
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// openAirRecord accumulates the lines of a single airspace, as we parse them.
+type openAirRecord struct {
+	Class, Name  string
+	Floor, Ceil  AltitudeLimit
+	Points     []Latlong
+	Center       Latlong
+	CenterSet    bool   // A V X= line was seen; (0,0) is a valid center
+	Clockwise    bool
+	Circle      *LatlongCircle
+}
+
+func newOpenAirRecord(class string) *openAirRecord {
+	return &openAirRecord{Class:class, Floor:KSurface, Ceil:KUnlimited, Clockwise:true}
+}
+
+// volume turns the record into an airspace volume; circles remain circles, everything else
+// becomes a polygon.
+func (r *openAirRecord)volume() (AirspaceVolume, error) {
+	v := AirspaceVolume{Name:r.Name, Class:r.Class, Floor:r.Floor, Ceil:r.Ceil}
+
+	if r.Circle != nil {
+		if len(r.Points) > 0 {
+			return v, fmt.Errorf("airspace '%s' has both a circle and points", r.Name)
+		}
+		v.Area = *r.Circle
+		return v, nil
+	}
+
+	if len(r.Points) < 3 {
+		return v, fmt.Errorf("airspace '%s' has only %d points", r.Name, len(r.Points))
+	}
+	poly := NewPolygon()
+	for _,pos := range r.Points { poly.AddPoint(pos) }
+	v.Area = poly
+	return v, nil
+}
+
+// {{{ parseOpenAirCoords, parseOpenAirAltitude
+
+// OpenAir coords are "DD:MM:SS N DDD:MM:SS W", or "DD:MM.mmm N DDD:MM.mmm W"
+var openAirCoordRe = regexp.MustCompile(
+	`(\d{1,3}):(\d{1,2}(?:\.\d+)?)(?::(\d{1,2}(?:\.\d+)?))?\s*([NSEW])`)
+
+// parseOpenAirCoords parses a list of latlongs, e.g. "37:00:00 N 122:00:00 W, 37:30.0 N 122:30.0 W"
+func parseOpenAirCoords(in string) ([]Latlong, error) {
+	matches := openAirCoordRe.FindAllStringSubmatch(in, -1)
+	if len(matches) == 0 || len(matches) % 2 != 0 {
+		return nil, fmt.Errorf("could not parse coords '%s'", in)
+	}
+
+	coord := func(m []string) float64 {
+		d,_ := strconv.ParseFloat(m[1], 64)
+		min,_ := strconv.ParseFloat(m[2], 64)
+		sec := 0.0
+		if m[3] != "" { sec,_ = strconv.ParseFloat(m[3], 64) }
+		dec := d + min/60.0 + sec/3600.0
+		if m[4] == "S" || m[4] == "W" { dec *= -1 }
+		return dec
+	}
+
+	ret := []Latlong{}
+	for i:=0; i<len(matches); i+=2 {
+		lat,long := matches[i], matches[i+1]
+		if !strings.Contains("NS", lat[4]) || !strings.Contains("EW", long[4]) {
+			return nil, fmt.Errorf("coords '%s' not in lat,long order", in)
+		}
+		ret = append(ret, Latlong{coord(lat), coord(long)})
+	}
+	return ret, nil
+}
+
+var openAirAltitudeRe = regexp.MustCompile(`^(\d+)\s*(?:FT|F)?\s*(MSL|AMSL|ALT|AGL|AGND|GND|SFC)?$`)
+var openAirFlightLevelRe = regexp.MustCompile(`^FL\s*(\d+)$`)
+
+// parseOpenAirAltitude parses limits such as "SFC", "UNL", "FL180", "2500ft MSL", "1000 AGL"
+func parseOpenAirAltitude(in string) (AltitudeLimit, error) {
+	s := strings.ToUpper(strings.TrimSpace(in))
+
+	switch s {
+	case "SFC", "GND":                         return KSurface, nil
+	case "UNL", "UNLIM", "UNLTD", "UNLIMITED": return KUnlimited, nil
+	}
+
+	if m := openAirFlightLevelRe.FindStringSubmatch(s); m != nil {
+		fl,_ := strconv.ParseFloat(m[1], 64)
+		return AltitudeLimit{fl * 100.0, FlightLevel}, nil
+	}
+
+	if m := openAirAltitudeRe.FindStringSubmatch(s); m != nil {
+		f,_ := strconv.ParseFloat(m[1], 64)
+		switch m[2] {
+		case "AGL", "AGND", "GND", "SFC": return FeetAGL(f), nil
+		default:                         return FeetMSL(f), nil
+		}
+	}
+
+	return AltitudeLimit{}, fmt.Errorf("could not parse altitude '%s'", in)
+}
+
+// }}}
+// {{{ arc
+
+// arc returns points along the arc of radius around the center, running from bearing b1 to b2,
+// in the given direction.
+func openAirArc(center Latlong, radiusKM, b1, b2 float64, clockwise bool) []Latlong {
+	if clockwise {
+		return Sector{Center:center, OuterRadiusKM:radiusKM, StartBearing:b1, EndBearing:b2}.arc(radiusKM)
+	}
+
+	// An anticlockwise arc is a clockwise arc the other way round
+	pts := Sector{Center:center, OuterRadiusKM:radiusKM, StartBearing:b2, EndBearing:b1}.arc(radiusKM)
+	for i,j := 0,len(pts)-1; i<j; i,j = i+1,j-1 {
+		pts[i],pts[j] = pts[j],pts[i]
+	}
+	return pts
+}
+
+// }}}
+
+// {{{ ParseOpenAir
+
+// ParseOpenAir reads airspace definitions in OpenAir format, returning one volume per airspace.
+// DA and DB arcs are approximated as points along the arc, so those airspaces become polygons;
+// a DC circle becomes a LatlongCircle. Styling records (SP, SB, etc.) are ignored.
+func ParseOpenAir(rdr io.Reader) ([]AirspaceVolume, error) {
+	ret := []AirspaceVolume{}
+	var rec *openAirRecord
+
+	flush := func() error {
+		if rec == nil { return nil }
+		v,err := rec.volume()
+		if err != nil { return err }
+		ret = append(ret, v)
+		rec = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(rdr)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "*") { continue }
+
+		fields := strings.SplitN(line, " ", 2)
+		cmd,arg := strings.ToUpper(fields[0]), ""
+		if len(fields) == 2 { arg = strings.TrimSpace(fields[1]) }
+
+		errf := func(format string, args ...interface{}) error {
+			return fmt.Errorf("openair line %d: %s", lineNum, fmt.Sprintf(format, args...))
+		}
+
+		if cmd == "AC" {
+			if err := flush(); err != nil { return nil, errf("%v", err) }
+			rec = newOpenAirRecord(arg)
+			continue
+		}
+
+		switch cmd {
+		case "AN", "AL", "AH", "DP", "V", "DA", "DB", "DC":
+			if rec == nil { return nil, errf("%s record before any AC record", cmd) }
+		default:
+			continue // SP, SB, AT, etc.
+		}
+
+		switch cmd {
+		case "AN":
+			rec.Name = arg
+
+		case "AL", "AH":
+			alt,err := parseOpenAirAltitude(arg)
+			if err != nil { return nil, errf("%v", err) }
+			if cmd == "AL" { rec.Floor = alt } else { rec.Ceil = alt }
+
+		case "DP":
+			pts,err := parseOpenAirCoords(arg)
+			if err != nil || len(pts) != 1 { return nil, errf("bad DP record '%s'", arg) }
+			rec.Points = append(rec.Points, pts[0])
+
+		case "V":
+			kv := strings.SplitN(arg, "=", 2)
+			if len(kv) != 2 { return nil, errf("bad V record '%s'", arg) }
+			switch strings.ToUpper(strings.TrimSpace(kv[0])) {
+			case "X":
+				pts,err := parseOpenAirCoords(kv[1])
+				if err != nil || len(pts) != 1 { return nil, errf("bad V X= record '%s'", arg) }
+				rec.Center,rec.CenterSet = pts[0], true
+			case "D":
+				rec.Clockwise = (strings.TrimSpace(kv[1]) != "-")
+			}
+
+		case "DA":
+			// DA radius(NM), startBearing, endBearing
+			vals := []float64{}
+			for _,s := range strings.Split(arg, ",") {
+				f,err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+				if err != nil { return nil, errf("bad DA record '%s'", arg) }
+				vals = append(vals, f)
+			}
+			if len(vals) != 3 { return nil, errf("bad DA record '%s'", arg) }
+			if !rec.CenterSet { return nil, errf("DA record without a V X= center") }
+			rec.Points = append(rec.Points,
+				openAirArc(rec.Center, NM2KM(vals[0]), vals[1], vals[2], rec.Clockwise)...)
+
+		case "DB":
+			// DB from, to - the radius is the distance from the center to the first point
+			pts,err := parseOpenAirCoords(arg)
+			if err != nil || len(pts) != 2 { return nil, errf("bad DB record '%s'", arg) }
+			if !rec.CenterSet { return nil, errf("DB record without a V X= center") }
+			c := rec.Center
+			rec.Points = append(rec.Points, openAirArc(c, c.DistKM(pts[0]), c.BearingTowards(pts[0]),
+				c.BearingTowards(pts[1]), rec.Clockwise)...)
+
+		case "DC":
+			nm,err := strconv.ParseFloat(arg, 64)
+			if err != nil { return nil, errf("bad DC record '%s'", arg) }
+			if !rec.CenterSet { return nil, errf("DC record without a V X= center") }
+			circle := rec.Center.Circle(NM2KM(nm))
+			rec.Circle = &circle
+		}
+	}
+	if err := scanner.Err(); err != nil { return nil, err }
+
+	if err := flush(); err != nil { return nil, fmt.Errorf("openair line %d: %v", lineNum, err) }
+	return ret, nil
+}
+
+// ReadOpenAirFile parses the named OpenAir file into an Airspace.
+func ReadOpenAirFile(filename string) (Airspace, error) {
+	f,err := os.Open(filename)
+	if err != nil { return Airspace{}, err }
+	defer f.Close()
+
+	vols,err := ParseOpenAir(f)
+	if err != nil { return Airspace{}, fmt.Errorf("%s: %v", filename, err) }
+
+	return Airspace{Name:filename, Volumes:vols}, nil
+}
+
+// }}}
+// {{{ v.PolygonRestriction
+
+// PolygonRestriction converts the volume into a restriction; circles are approximated by
+// polygons. AGL limits are treated as MSL, and flight levels as feet, as a PolygonRestriction
+// has no notion of the ground or of pressure; an unlimited ceiling becomes no maximum.
+func (v AirspaceVolume)PolygonRestriction() (PolygonRestriction, error) {
+	pr := PolygonRestriction{Name:v.Name}
+
+	switch a := v.Area.(type) {
+	case *Polygon:      pr.Polygon = a
+	case LatlongCircle: pr.Polygon = a.ToPolygon(kCirclePolygonSides)
+	default:
+		return pr, fmt.Errorf("volume %s: can't make a polygon from %T", v.Name, v.Area)
+	}
+
+	if !v.Floor.IsSurface() { pr.AltitudeMin = int64(v.Floor.Feet) }
+	if !v.Ceil.IsUnlimited() { pr.AltitudeMax = int64(v.Ceil.Feet) }
+
+	return pr, nil
+}
+
+// }}}
+
+// {{{ -------------------------={ E N D }=----------------------------------
+
+// Local variables:
+// folded-file: t
+// end:
+
+// }}}
@@ -0,0 +1,145 @@
+package geo
+
+import(
+	"math"
+	"strings"
+	"testing"
+)
+
+var testOpenAir = `
+* A made-up file, near SFO
+AC D
+AN TEST DELTA
+AL SFC
+AH 2500ft MSL
+V X=37:30:00 N 122:15:00 W
+DC 4.3
+
+AC R
+AN TEST RESTRICTED
+AL 1000 AGL
+AH FL180
+SP 0,1,255,0,0
+DP 37:00:00 N 122:00:00 W
+DP 37:00:00 N 121:50:00 W
+DP 36:50:00 N 121:50:00 W
+DP 36:50:00 N 122:00:00 W
+
+AC C
+AN TEST CHARLIE
+AL 1500
+AH 4000
+V X=37:40.0 N 122:10.0 W
+DP 37:40.0 N 122:10.0 W
+DA 10,0,90
+
+AC C
+AN TEST CHARLIE NORTH
+AL 4000
+AH 10000
+V X=37:40.0 N 122:10.0 W
+V D=-
+DB 37:40.0 N 121:57.38 W, 37:40:00 N 122:22:37.2 W
+`
+
+func TestParseOpenAir(t *testing.T) {
+	vols,err := ParseOpenAir(strings.NewReader(testOpenAir))
+	if err != nil { t.Fatalf("ParseOpenAir: %v", err) }
+	if len(vols) != 4 { t.Fatalf("expected 4 volumes, saw %d", len(vols)) }
+
+	delta,restricted,charlie,north := vols[0], vols[1], vols[2], vols[3]
+
+	if delta.Name != "TEST DELTA" || delta.Class != "D" { t.Errorf("bad delta: %s", delta) }
+	if c,ok := delta.Area.(LatlongCircle); !ok {
+		t.Errorf("delta area: expected a circle, saw %T", delta.Area)
+	} else if math.Abs(c.RadiusKM - NM2KM(4.3)) > 0.001 || c.Latlong != (Latlong{37.5, -122.25}) {
+		t.Errorf("delta circle wrong: %s", c)
+	}
+	if !delta.Floor.IsSurface() || delta.Ceil != FeetMSL(2500) {
+		t.Errorf("delta limits wrong: %s, %s", delta.Floor, delta.Ceil)
+	}
+
+	if restricted.Floor != FeetAGL(1000) || restricted.Ceil.String() != "FL180" {
+		t.Errorf("restricted limits wrong: %s, %s", restricted.Floor, restricted.Ceil)
+	}
+	if !restricted.Area.Contains(Latlong{36.9, -121.9}) || restricted.Area.Contains(Latlong{37.1, -121.9}) {
+		t.Errorf("restricted area wrong: %s", restricted.Area)
+	}
+
+	// The first charlie is a clockwise quarter-circle from north to east; the second runs
+	// anticlockwise from east to west, making the northern half of the circle.
+	center := Latlong{37+40.0/60.0, -(122+10.0/60.0)}
+	containsTests := []struct{
+		Vol      AirspaceVolume
+		Bearing  float64
+		Expected bool
+	}{
+		{charlie,  45, true},
+		{charlie, 315, false},
+		{charlie, 135, false},
+		{north,    45, true},
+		{north,   315, true},
+		{north,   135, false},
+		{north,   225, false},
+	}
+	for i,test := range containsTests {
+		pos := center.MoveKM(test.Bearing, NM2KM(8))
+		if actual := test.Vol.Area.Contains(pos); actual != test.Expected {
+			t.Errorf("contains[%d] %s: bearing %.0f, expected %v, saw %v", i, test.Vol.Name,
+				test.Bearing, test.Expected, actual)
+		}
+	}
+
+	pr,err := charlie.PolygonRestriction()
+	if err != nil { t.Fatalf("PolygonRestriction: %v", err) }
+	if pr.Name != "TEST CHARLIE" || pr.AltitudeMin != 1500 || pr.AltitudeMax != 4000 {
+		t.Errorf("bad restriction: %s", pr)
+	}
+	if pr,err := delta.PolygonRestriction(); err != nil || pr.AltitudeMin != 0 || pr.IsNil() {
+		t.Errorf("bad delta restriction: %s (%v)", pr, err)
+	}
+}
+
+func TestParseOpenAirErrors(t *testing.T) {
+	tests := []string{
+		"AN NO CLASS\n",
+		"AC D\nAN NO POINTS\nAL SFC\n",
+		"AC D\nAN BAD ALT\nAL LOW\n",
+		"AC D\nAN NO CENTER\nDC 5\n",
+		"AC D\nAN BAD POINT\nDP 37 N 122 W\n",
+	}
+	for i,test := range tests {
+		if _,err := ParseOpenAir(strings.NewReader(test)); err == nil {
+			t.Errorf("[%d] expected an error, got none", i)
+		}
+	}
+
+	// A center at (0,0) is still a center
+	zero := "AC D\nAN NULL ISLAND\nV X=00:00:00 N 000:00:00 E\nDC 5\n"
+	if vols,err := ParseOpenAir(strings.NewReader(zero)); err != nil || len(vols) != 1 || !vols[0].Area.Contains(Latlong{0.01, 0.01}) {
+		t.Errorf("center at (0,0): saw %v (%v)", vols, err)
+	}
+}
+
+func TestParseOpenAirAltitude(t *testing.T) {
+	tests := []struct{
+		In       string
+		Expected AltitudeLimit
+	}{
+		{"SFC",        KSurface},
+		{"GND",        KSurface},
+		{"UNLTD",      KUnlimited},
+		{"FL 65",      AltitudeLimit{6500, FlightLevel}},
+		{"2500ft MSL", FeetMSL(2500)},
+		{"2500 ALT",   FeetMSL(2500)},
+		{"3000",       FeetMSL(3000)},
+		{"1000ft AGL", FeetAGL(1000)},
+		{"500 GND",    FeetAGL(500)},
+	}
+	for i,test := range tests {
+		actual,err := parseOpenAirAltitude(test.In)
+		if err != nil || actual != test.Expected {
+			t.Errorf("[%d] %q: expected %s, saw %s (%v)", i, test.In, test.Expected, actual, err)
+		}
+	}
+}
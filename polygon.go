@@ -2,31 +2,52 @@ package geo
 
 import(
 	"fmt"
+	"sync/atomic"
 	pmgeo "github.com/paulmach/go.geo"  // https://godoc.org/github.com/paulmach/go.geo
 )
 
+// The transients (the closed path, and the bound) are derived from Path on first use, such as
+// the first read after a gob decode, and cached; AddPoint invalidates them. The cache is safe
+// for concurrent reads, but not for reads during modification. If you edit Path.PointSet
+// directly, call Invalidate afterwards.
 type Polygon struct {
 	*pmgeo.Path
-	closedPath *pmgeo.Path // transient
+	transients atomic.Value // *polygonTransients; nil if not yet derived
 }
 func NewPolygon() *Polygon { return &Polygon{ Path: pmgeo.NewPath() } }
 
+type polygonTransients struct {
+	closedPath *pmgeo.Path  // a copy, so it doesn't share storage with Path
+	bound      *pmgeo.Bound
+}
+
 func (poly *Polygon)String() string {
 	return fmt.Sprintf("Poly n=%d, center=%s, avg radius=%.2fKM",
 		len(poly.Path.Points()), poly.Centroid(), poly.ApproxRadiusKM())
 }
 
-func (poly *Polygon)setClosedPath() {
-	if poly.closedPath != nil {
-		return
-	}
-	pts := poly.Path.Points()
-	pts = append(pts, pts[0]) // Close the path
+// getTransients derives the transients if needed. Concurrent readers may both derive them,
+// but they get the same answer.
+func (poly *Polygon)getTransients() *polygonTransients {
+	if pt,_ := poly.transients.Load().(*polygonTransients); pt != nil { return pt }
+
+	pts := append([]pmgeo.Point{}, poly.Path.Points()...)
+	if len(pts) > 0 { pts = append(pts, pts[0]) } // Close the path
 
-	poly.closedPath = pmgeo.NewPath()
-	poly.closedPath.PointSet = pts
+	pt := &polygonTransients{closedPath:pmgeo.NewPath(), bound:poly.Path.Bound()}
+	pt.closedPath.PointSet = pts
+	poly.transients.Store(pt)
+	return pt
 }
 
+// Invalidate drops the transients, so they are derived afresh from Path.
+func (poly *Polygon)Invalidate() { poly.transients.Store((*polygonTransients)(nil)) }
+
+func (poly *Polygon)getClosedPath() *pmgeo.Path { return poly.getTransients().closedPath }
+func (poly *Polygon)getBound() *pmgeo.Bound { return poly.getTransients().bound }
+
+func (poly *Polygon)BoundingBox() LatlongBox { return LatlongBoxFromBound(poly.getBound()) }
+
 func (poly *Polygon)GetPoints() []Latlong {
 	ret := []Latlong{}
 	for _,pt := range poly.Path.Points() {
@@ -38,8 +59,7 @@ func (poly *Polygon)GetPoints() []Latlong {
 // A slice of sides, in clockwise order, where each side is represented
 // as a slice of two points {Start, End}
 func (poly *Polygon)GetSides() [][]Latlong {
-	poly.setClosedPath()
-	pts := poly.closedPath.Points()
+	pts := poly.getClosedPath().Points()
 	ret := [][]Latlong{}
 	for i:=1; i<len(pts); i++ {
 		ret = append(ret, []Latlong{LatlongFromPt(&pts[i-1]), LatlongFromPt(&pts[i])})
@@ -76,15 +96,14 @@ func (poly *Polygon)ApproxRadiusKM() float64 {
 // Order matters.
 func (poly *Polygon)AddPoint(ll Latlong) {
 	poly.Path.PointSet = append(poly.Path.PointSet, *(ll.Pt()))
+	poly.Invalidate()
 }
 
 // Note; when a line intersects a vertex, it may be found to intersect lines on both sides,
 // and so may contribute that vertex point more than once. So we dedupe.
 func (poly *Polygon)IntersectsLine(l LatlongLine) ([]Latlong, bool) {
-	poly.setClosedPath()
-
 	ret := []Latlong{}
-	pts,_ := poly.closedPath.IntersectionLine(l.Ln())
+	pts,_ := poly.getClosedPath().IntersectionLine(l.Ln())
 
 	deduped := []*pmgeo.Point{}
 	for i:=0; i<len(pts); i++ {
@@ -105,30 +124,18 @@ func (poly *Polygon)IntersectsLine(l LatlongLine) ([]Latlong, bool) {
 	return ret, (len(ret)>0)
 }
 
-// This is *so* similar to LatlongBox.OverlapsLine ...
 func (poly *Polygon)OverlapsLine(l LatlongLine) OverlapOutcome {
 	// Trivial bounding box test; discard if the line (as a box) has no overlap
-	if !poly.Path.Bound().Intersects(l.Bound()) { return Disjoint }
+	if !poly.getBound().Intersects(l.Bound()) { return Disjoint }
 	
-	// If either endpoint is in the box, we're containing or straddling.
 	// TODO: make this less horrifyingly expensive.
-	sInside,eInside := poly.Contains(l.From), poly.Contains(l.To)
-
-	// r2 is the line. If any of it is inside, figure out the line's relation to the box
-	if sInside && eInside { return OverlapR2IsContained }
-	if sInside            { return OverlapR2StraddlesEnd }
-	if eInside            { return OverlapR2StraddlesStart }
-
-	// Points are outside. So, if we intersect, then the line contains the poly.
-	if _,intersects := poly.IntersectsLine(l); intersects {
-		return OverlapR2Contains
-	} else {
-		return Disjoint
-	}
+	crosses := func(l LatlongLine) bool { _,intersects := poly.IntersectsLine(l); return intersects }
+	return lineOverlap(l, poly.Contains, crosses)
 }
 
 func (p *Polygon)Contains(ll Latlong) bool {
-	if !p.Path.Bound().Contains(ll.Pt()) { return false }
+	bound := p.getBound()
+	if !bound.Contains(ll.Pt()) { return false }
 
   // Contains: build 'ray' from p to (0,0), intersect with path, and
   // expect an odd number of intersections.
@@ -137,7 +144,7 @@ func (p *Polygon)Contains(ll Latlong) bool {
 	// Also, the ray can't be coincident with any side of the polygon; TODO.
 	rayEnd := Latlong{0,0}
 	for {
-		if !p.Path.Bound().Contains(rayEnd.Pt()) { break }
+		if !bound.Contains(rayEnd.Pt()) { break }
 		rayEnd.Lat  += 10
 		rayEnd.Long += 11.7
 	}
@@ -149,6 +156,19 @@ func (p *Polygon)Contains(ll Latlong) bool {
 	return (rem == 1)  // if odd, we intersect
 }
 
+// NearestBoundaryPoint returns the point on the perimeter of the polygon that is closest to pos.
+func (poly *Polygon)NearestBoundaryPoint(pos Latlong) Latlong {
+	return closestOnLines(poly.ToLines(), pos)
+}
+
+// SignedDistanceKM is the distance from pos to the nearest edge of the polygon; it is negative
+// if pos lies inside the polygon.
+func (poly *Polygon)SignedDistanceKM(pos Latlong) float64 {
+	dist := pos.DistKM(poly.NearestBoundaryPoint(pos))
+	if poly.Contains(pos) { return -1 * dist }
+	return dist
+}
+
 
 // Implement MapRenderer
 func (poly *Polygon)ToCircles() []LatlongCircle { return nil }
@@ -2,8 +2,12 @@ package geo
 
 // go test -v github.com/skypies/geo
 
-import "fmt"
-import "testing"
+import(
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
 
 func TestPolygon(t *testing.T) {
 	poly := NewPolygon()
@@ -100,3 +104,56 @@ func TestOverlapsLine(t *testing.T) {
 		}
 	}
 }
+
+func TestPolygonSignedDistance(t *testing.T) {
+	poly := NewPolygon()
+	poly.AddPoint(Latlong{ 0, 0})
+	poly.AddPoint(Latlong{ 0, 1})
+	poly.AddPoint(Latlong{ 1, 1})
+	poly.AddPoint(Latlong{ 1, 0})
+
+	tests := []struct{
+		Pos, Nearest Latlong
+		DistKM       float64 // Roughly 111KM per degree at the equator
+	}{
+		{Latlong{0.5, 0.5}, Latlong{0.5, 1.0}, -55.6}, // center; longitude degrees are a touch shorter
+		{Latlong{0.5, 0.9}, Latlong{0.5, 1.0}, -11.1},
+		{Latlong{0.5, 1.5}, Latlong{0.5, 1.0},  55.6},
+		{Latlong{2.0, 2.0}, Latlong{1.0, 1.0}, 157.2}, // closest to a vertex
+	}
+
+	for i,test := range tests {
+		nearest := poly.NearestBoundaryPoint(test.Pos)
+		if !nearest.Equal(test.Nearest) {
+			t.Errorf("NearestBoundaryPoint[%d]: expected %s, saw %s\n", i, test.Nearest, nearest)
+		}
+		if dist := poly.SignedDistanceKM(test.Pos); math.Abs(dist - test.DistKM) > 0.1 {
+			t.Errorf("SignedDistanceKM[%d]: expected %.1f, saw %.1f\n", i, test.DistKM, dist)
+		}
+	}
+}
+
+func TestPolygonTransients(t *testing.T) {
+	poly := NewPolygon()
+	for _,pos := range []Latlong{{0,0}, {0,10}, {10,10}, {10,0}} { poly.AddPoint(pos) }
+
+	// Concurrent reads (run with -race)
+	var wg sync.WaitGroup
+	for i:=0; i<8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !poly.Contains(Latlong{5,5}) { t.Errorf("concurrent Contains failed") }
+			poly.BoundingBox()
+		}()
+	}
+	wg.Wait()
+
+	// After editing the points directly, Invalidate drops the stale transients
+	poly.Path.PointSet[2] = *(Latlong{20,20}.Pt())
+	if poly.Contains(Latlong{15,15}) { t.Errorf("edited polygon: transients were not cached") }
+	poly.Invalidate()
+	if !poly.Contains(Latlong{15,15}) { t.Errorf("edited polygon: stale transients") }
+	if !poly.BoundingBox().Contains(Latlong{19,19}) { t.Errorf("edited polygon: stale bound") }
+	if n := len(poly.GetSides()); n != 4 { t.Errorf("edited polygon: expected 4 sides, saw %d", n) }
+}
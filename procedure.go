@@ -1,6 +1,9 @@
 package geo
 
-import "fmt"
+import(
+	"fmt"
+	"time"
+)
 
 type Waypoint struct {
 	FixName     string
@@ -18,6 +21,9 @@ type Procedure struct {
 	Departure   bool     // If false, is arrival
 	Airport     string   // Where we are arriving or departing from
 	Waypoints []Waypoint
+
+	Effective   time.Time // When the procedure came into effect; zero if not known
+	Until       time.Time // When it was replaced; zero if still in force
 }
 
 func (p Procedure)String() string {
@@ -31,10 +37,20 @@ func (p Procedure)String() string {
 	return str
 }
 
-func (p *Procedure)Populate(fixes map[string]Latlong) {
+// InForceAt says whether the procedure was published at the time.
+func (p Procedure)InForceAt(t time.Time) bool { return inForce(p.Effective, p.Until, t) }
+
+// Populate fills in the positions of the waypoints. Unknown fixes are left at {0,0}, and
+// reported in the error.
+func (p *Procedure)Populate(fixes FixLookup) error {
+	unknown := []string{}
 	for i,_ := range p.Waypoints {
-		p.Waypoints[i].Latlong = fixes[p.Waypoints[i].FixName]
+		nl,exists := fixes.Lookup(p.Waypoints[i].FixName)
+		if !exists { unknown = append(unknown, p.Waypoints[i].FixName) }
+		p.Waypoints[i].Latlong = nl.Latlong
 	}
+	if len(unknown) > 0 { return fmt.Errorf("procedure %s: unknown fixes %v", p.Name, unknown) }
+	return nil
 }
 
 // ComparisonLines returns the segments of the procedure that a track's boxes need to intersect and
@@ -48,3 +64,23 @@ func (p Procedure)ComparisonLines() []LatlongLine {
 	}
 	return ret
 }
+
+// ProcedureSet holds procedures over time; e.g. SERFR2, and the SERFR3 that replaced it.
+type ProcedureSet []Procedure
+
+// InForce returns the procedures published at the time.
+func (ps ProcedureSet)InForce(t time.Time) ProcedureSet {
+	ret := ProcedureSet{}
+	for _,p := range ps {
+		if p.InForceAt(t) { ret = append(ret, p) }
+	}
+	return ret
+}
+
+// Lookup returns the named procedure, as published at the time.
+func (ps ProcedureSet)Lookup(name string, t time.Time) (Procedure, bool) {
+	for _,p := range ps {
+		if p.Name == name && p.InForceAt(t) { return p, true }
+	}
+	return Procedure{}, false
+}
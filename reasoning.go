@@ -0,0 +1,76 @@
+package geo
+
+import(
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ReasoningStep is a single fact established during an analysis. It holds either a numeric
+// Value (with a Unit), or a Text value, or neither (in which case the Fact stands alone.)
+type ReasoningStep struct {
+	Fact      string
+	Value     *float64  `json:",omitempty"`
+	Unit      string    `json:",omitempty"`  // e.g. "ft", "NM", "kt", "deg", "inHg"
+	Text      string    `json:",omitempty"`
+	Emphasize bool      `json:",omitempty"`  // Renderers may choose to highlight the value
+}
+
+// Reasoning is an explanation of how an analysis reached its verdict; it is independent of how
+// it will be rendered.
+type Reasoning []ReasoningStep
+
+// Fact records a fact with a numeric value.
+func (r *Reasoning)Fact(fact string, value float64, unit string) {
+	*r = append(*r, ReasoningStep{Fact:fact, Value:&value, Unit:unit})
+}
+// KeyFact is a Fact that renderers should highlight.
+func (r *Reasoning)KeyFact(fact string, value float64, unit string) {
+	*r = append(*r, ReasoningStep{Fact:fact, Value:&value, Unit:unit, Emphasize:true})
+}
+// TextFact records a fact with a non-numeric value.
+func (r *Reasoning)TextFact(fact, text string) {
+	*r = append(*r, ReasoningStep{Fact:fact, Text:text})
+}
+// Note records a fact that has no value.
+func (r *Reasoning)Note(fact string) {
+	*r = append(*r, ReasoningStep{Fact:fact})
+}
+
+// ValueString formats the value with a precision that suits its unit.
+func (s ReasoningStep)ValueString() string {
+	if s.Value == nil { return s.Text }
+	switch s.Unit {
+	case "ft", "kt": return fmt.Sprintf("%.0f%s", *s.Value, s.Unit)
+	case "inHg":     return fmt.Sprintf("%.2f%s", *s.Value, s.Unit)
+	default:         return fmt.Sprintf("%.1f%s", *s.Value, s.Unit)
+	}
+}
+
+// Renderers
+
+func (r Reasoning)String() string {
+	str := ""
+	for _,s := range r {
+		str += "* " + s.Fact
+		if v := s.ValueString(); v != "" { str += ": " + v }
+		str += "\n"
+	}
+	return str
+}
+
+func (r Reasoning)HTML() string {
+	lines := []string{}
+	for _,s := range r {
+		line := html.EscapeString(s.Fact)
+		if v := html.EscapeString(s.ValueString()); v != "" {
+			if s.Emphasize { v = "<b>" + v + "</b>" }
+			line += ": " + v
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "<br/>\n")
+}
+
+func (r Reasoning)JSON() ([]byte, error) { return json.Marshal(r) }
@@ -1,8 +1,10 @@
 package geo
 
 import(
+	"bytes"
 	"encoding/gob"
 	"fmt"
+	"math"
 )
 
 func init() {
@@ -10,6 +12,10 @@ func init() {
 	gob.Register(SquareBoxRestriction{})
 	gob.Register(VerticalPlaneRestriction{})
 	gob.Register(PolygonRestriction{})
+	gob.Register(CircleRestriction{})
+	gob.Register(SectorRestriction{})
+	gob.Register(AnnulusRestriction{})
+	gob.Register(ClassBRestriction{})
 }
 
 type DebugLog string
@@ -61,11 +67,14 @@ func (sb SquareBoxRestriction)Contains(pos Latlong) bool {
 func (sb SquareBoxRestriction)OverlapsLine(ln LatlongLine) OverlapOutcome {
 	return sb.Box(sb.SideKM,sb.SideKM).OverlapsLine(ln)
 }
+func (sb SquareBoxRestriction)NearestBoundaryPoint(pos Latlong) Latlong {
+	return sb.Box(sb.SideKM,sb.SideKM).NearestBoundaryPoint(pos)
+}
+func (sb SquareBoxRestriction)SignedDistanceKM(pos Latlong) float64 {
+	return sb.Box(sb.SideKM,sb.SideKM).SignedDistanceKM(pos)
+}
 func (sb SquareBoxRestriction)OverlapsAltitude(a int64) OverlapOutcome {
-	// r2 is the altitude; so if too low, it comes 'before' the restriction
-	if sb.AltitudeMin > 0 &&  a < sb.AltitudeMin { return DisjointR2ComesBefore }
-	if sb.AltitudeMax > 0 &&  a > sb.AltitudeMax { return DisjointR2ComesAfter }
-	return OverlapR2IsContained
+	return altitudeOverlap(a, sb.AltitudeMin, sb.AltitudeMax)
 }
 
 // VerticalPlane  fully implements geo.Restrictor
@@ -109,23 +118,25 @@ func (vp VerticalPlaneRestriction)OverlapsLine(ln LatlongLine) OverlapOutcome {
 	}
 }
 func (vp VerticalPlaneRestriction)OverlapsAltitude(a int64) OverlapOutcome {
-	// r2 is the altitude; so if too low, it comes 'before' the restriction
-	if vp.AltitudeMin > 0 &&  a < vp.AltitudeMin { return DisjointR2ComesBefore }
-	if vp.AltitudeMax > 0 &&  a > vp.AltitudeMax { return DisjointR2ComesAfter }
-	return OverlapR2IsContained
+	return altitudeOverlap(a, vp.AltitudeMin, vp.AltitudeMax)
 }
 
 
-// PolygonRestricton fully implements geo.Restrictor
+// PolygonRestricton fully implements geo.Restrictor. Contains and OverlapsLine are delegated
+// explicitly to the polygon, rather than leaking through the embedding.
 type PolygonRestriction struct {
 	*Polygon
-	SideKM                   float64
+	Name                     string // Optional; used in String()
 	AltitudeMin,AltitudeMax  int64
 	IsExcluding              bool
 	Debugger                 // embed; populate with ptr rcvr e.g. pr.Debugger = new(geo.DebugLog)
 }
 func (pr PolygonRestriction)String() string {
-	str := fmt.Sprintf("%d-gon ~%.2fKM @ %s", len(pr.Polygon.Path.Points()),
+	if pr.IsNil() { return "Polygon [nil]" }
+
+	str := "Polygon "
+	if pr.Name != "" { str += pr.Name + " " }
+	str += fmt.Sprintf("%d-gon ~%.2fKM @ %s", len(pr.Polygon.Path.Points()),
 		pr.Polygon.ApproxRadiusKM(), pr.Polygon.Centroid())
 
 	if pr.AltitudeMin > 0 || pr.AltitudeMax > 0 {
@@ -140,21 +151,265 @@ func (pr PolygonRestriction)String() string {
 
 func (pr PolygonRestriction)IsExclusion() bool { return pr.IsExcluding }
 func (pr PolygonRestriction)IsNil() bool {
-	return pr.Polygon == nil || len(pr.Polygon.Path.Points()) < 3
+	return pr.Polygon == nil || pr.Polygon.Path == nil || len(pr.Polygon.Path.Points()) < 3
 }
 
 func (pr PolygonRestriction)ToCircles() []LatlongCircle { return nil }
 func (pr PolygonRestriction)ToLines() []LatlongLine { return pr.Polygon.ToLines() }
 
-func (pr PolygonRestriction)BoundingBox() LatlongBox { return LatlongBoxFromBound(pr.Bound()) }
+// The bounding box is derived on first use (e.g. after a gob decode), and cached in the polygon
+func (pr PolygonRestriction)BoundingBox() LatlongBox { return pr.Polygon.BoundingBox() }
 func (pr PolygonRestriction)CanContain() bool { return true }
-//func (pr PolygonRestriction)Contains(pos Latlong) bool { return pr.Polygon.Contains(pos) }
-//func (pr PolygonRestriction)OverlapsLine(ln LatlongLine) OverlapOutcome {
-//	return pr.Box(pr.SideKM,pr.SideKM).OverlapsLine(ln)
-//}
+func (pr PolygonRestriction)Contains(pos Latlong) bool { return pr.Polygon.Contains(pos) }
+func (pr PolygonRestriction)OverlapsLine(ln LatlongLine) OverlapOutcome {
+	return pr.Polygon.OverlapsLine(ln)
+}
+func (pr PolygonRestriction)NearestBoundaryPoint(pos Latlong) Latlong {
+	return pr.Polygon.NearestBoundaryPoint(pos)
+}
+func (pr PolygonRestriction)SignedDistanceKM(pos Latlong) float64 {
+	return pr.Polygon.SignedDistanceKM(pos)
+}
 func (pr PolygonRestriction)OverlapsAltitude(a int64) OverlapOutcome {
+	return altitudeOverlap(a, pr.AltitudeMin, pr.AltitudeMax)
+}
+
+
+// CircleRestriction fully implements geo.Restrictor; it is a cylinder, if altitudes are given
+type CircleRestriction struct {
+	NamedLatlong             // embed; the center
+	RadiusKM                 float64
+	AltitudeMin,AltitudeMax  int64
+	IsExcluding              bool
+	Debugger                 // embed; populate with ptr rcvr e.g. cr.Debugger = new(geo.DebugLog)
+}
+func (cr CircleRestriction)String() string {
+	nstr := cr.Name
+	if nstr == "" { nstr = cr.Latlong.String() }
+	str := fmt.Sprintf("Circle %s @%.1fKM", nstr, cr.RadiusKM)
+	if cr.AltitudeMin > 0 || cr.AltitudeMax > 0 {
+		str += fmt.Sprintf(" [%d,", cr.AltitudeMin)
+		if cr.AltitudeMax>0 { str += fmt.Sprintf("%d",cr.AltitudeMax) } else { str += "-" }
+		str += "]ft"
+	}
+
+	if cr.IsExcluding { str += "(EXCLUDES)" }
+	return str
+}
+
+func (cr CircleRestriction)Circle() LatlongCircle { return cr.Latlong.Circle(cr.RadiusKM) }
+
+func (cr CircleRestriction)IsExclusion() bool { return cr.IsExcluding }
+func (cr CircleRestriction)IsNil() bool { return cr.RadiusKM==0 || cr.NamedLatlong.IsNil() }
+
+func (cr CircleRestriction)ToCircles() []LatlongCircle { return cr.Circle().ToCircles() }
+func (cr CircleRestriction)ToLines() []LatlongLine { return cr.Circle().ToLines() }
+
+func (cr CircleRestriction)BoundingBox() LatlongBox { return cr.Circle().BoundingBox() }
+func (cr CircleRestriction)CanContain() bool { return true }
+func (cr CircleRestriction)Contains(pos Latlong) bool { return cr.Circle().Contains(pos) }
+func (cr CircleRestriction)OverlapsLine(ln LatlongLine) OverlapOutcome {
+	return cr.Circle().OverlapsLine(ln)
+}
+func (cr CircleRestriction)NearestBoundaryPoint(pos Latlong) Latlong {
+	return cr.Circle().NearestBoundaryPoint(pos)
+}
+func (cr CircleRestriction)SignedDistanceKM(pos Latlong) float64 {
+	return cr.Circle().SignedDistanceKM(pos)
+}
+func (cr CircleRestriction)OverlapsAltitude(a int64) OverlapOutcome {
+	return altitudeOverlap(a, cr.AltitudeMin, cr.AltitudeMax)
+}
+
+
+// SectorRestriction fully implements geo.Restrictor
+type SectorRestriction struct {
+	Sector                   // embed
+	AltitudeMin,AltitudeMax  int64
+	IsExcluding              bool
+	Debugger                 // embed; populate with ptr rcvr e.g. sr.Debugger = new(geo.DebugLog)
+}
+func (sr SectorRestriction)String() string {
+	str := sr.Sector.String()
+	if sr.AltitudeMin > 0 || sr.AltitudeMax > 0 {
+		str += fmt.Sprintf(" [%d,", sr.AltitudeMin)
+		if sr.AltitudeMax>0 { str += fmt.Sprintf("%d",sr.AltitudeMax) } else { str += "-" }
+		str += "]ft"
+	}
+
+	if sr.IsExcluding { str += "(EXCLUDES)" }
+	return str
+}
+
+func (sr SectorRestriction)IsExclusion() bool { return sr.IsExcluding }
+func (sr SectorRestriction)IsNil() bool { return sr.OuterRadiusKM==0 || sr.Center.IsNil() }
+
+func (sr SectorRestriction)CanContain() bool { return true }
+func (sr SectorRestriction)OverlapsAltitude(a int64) OverlapOutcome {
+	return altitudeOverlap(a, sr.AltitudeMin, sr.AltitudeMax)
+}
+
+// AnnulusRestriction fully implements geo.Restrictor
+type AnnulusRestriction struct {
+	Annulus                  // embed
+	AltitudeMin,AltitudeMax  int64
+	IsExcluding              bool
+	Debugger                 // embed; populate with ptr rcvr e.g. ar.Debugger = new(geo.DebugLog)
+}
+func (ar AnnulusRestriction)String() string {
+	str := ar.Annulus.String()
+	if ar.AltitudeMin > 0 || ar.AltitudeMax > 0 {
+		str += fmt.Sprintf(" [%d,", ar.AltitudeMin)
+		if ar.AltitudeMax>0 { str += fmt.Sprintf("%d",ar.AltitudeMax) } else { str += "-" }
+		str += "]ft"
+	}
+
+	if ar.IsExcluding { str += "(EXCLUDES)" }
+	return str
+}
+
+func (ar AnnulusRestriction)IsExclusion() bool { return ar.IsExcluding }
+func (ar AnnulusRestriction)IsNil() bool { return ar.OuterRadiusKM==0 || ar.Center.IsNil() }
+
+func (ar AnnulusRestriction)CanContain() bool { return true }
+func (ar AnnulusRestriction)OverlapsAltitude(a int64) OverlapOutcome {
+	return altitudeOverlap(a, ar.AltitudeMin, ar.AltitudeMax)
+}
+
+
+// ClassBRestriction fully implements geo.Restrictor, for either a whole ClassB map, or just one
+// of its shelves (e.g. Shelf:"100/30"; a shelf may span several sectors.) As the floor of a map
+// varies with position, OverlapsAltitude can only test against the envelope of all the shelves;
+// evaluate track points with OverlapsAltitudeAt, which uses the floor at the point.
+// Create it with NewClassBRestriction, so the volumes are built just once.
+type ClassBRestriction struct {
+	Map                      ClassBMap
+	Shelf                    string // Optional; "ceil/floor", in hundreds of feet
+	IsExcluding              bool
+	Debugger                 // embed; populate with ptr rcvr e.g. cb.Debugger = new(geo.DebugLog)
+
+	vols                   []AirspaceVolume // built by NewClassBRestriction
+}
+
+func NewClassBRestriction(m ClassBMap, shelf string, isExcluding bool) (ClassBRestriction, error) {
+	cb := ClassBRestriction{Map:m, Shelf:shelf, IsExcluding:isExcluding}
+	if shelf != "" {
+		if _,_,err := parseClassBShelf(shelf); err != nil { return cb, err }
+	}
+	cb.vols = cb.buildVolumes()
+	return cb, nil
+}
+
+func (cb ClassBRestriction)String() string {
+	str := "ClassB " + cb.Map.Name
+	if cb.Shelf != "" { str += " " + cb.Shelf + " shelf" }
+	if cb.IsExcluding { str += "(EXCLUDES)" }
+	return str
+}
+
+// parseClassBShelf parses "100/30" into the ceiling and floor, in hundreds of feet.
+func parseClassBShelf(shelf string) (int, int, error) {
+	ceil,floor := 0,0
+	if n,err := fmt.Sscanf(shelf, "%d/%d", &ceil, &floor); n != 2 || err != nil {
+		return 0, 0, fmt.Errorf("ClassB shelf '%s': not 'ceil/floor'", shelf)
+	}
+	return ceil, floor, nil
+}
+
+func (cb ClassBRestriction)buildVolumes() []AirspaceVolume {
+	if cb.Shelf == "" { return cb.Map.volumes(func(Cylinder) bool { return true }) }
+	ceil,floor,err := parseClassBShelf(cb.Shelf)
+	if err != nil { return []AirspaceVolume{} }
+	return cb.Map.volumes(func(c Cylinder) bool { return c.Ceil == ceil && c.Floor == floor })
+}
+
+// volumes are those built at creation; a restriction made without NewClassBRestriction has to
+// build them on every call.
+func (cb ClassBRestriction)volumes() []AirspaceVolume {
+	if cb.vols != nil { return cb.vols }
+	return cb.buildVolumes()
+}
+
+// The volumes are not exported, so gob needs some help to rebuild them on decode.
+type gobClassBRestriction struct {
+	Map          ClassBMap
+	Shelf        string
+	IsExcluding  bool
+	Debugger     Debugger
+}
+func (cb ClassBRestriction)GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(gobClassBRestriction{cb.Map, cb.Shelf, cb.IsExcluding, cb.Debugger})
+	return buf.Bytes(), err
+}
+func (cb *ClassBRestriction)GobDecode(data []byte) error {
+	g := gobClassBRestriction{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&g); err != nil { return err }
+	*cb = ClassBRestriction{Map:g.Map, Shelf:g.Shelf, IsExcluding:g.IsExcluding, Debugger:g.Debugger}
+	cb.vols = cb.buildVolumes()
+	return nil
+}
+
+func (cb ClassBRestriction)IsExclusion() bool { return cb.IsExcluding }
+func (cb ClassBRestriction)IsNil() bool { return len(cb.volumes()) == 0 }
+
+func (cb ClassBRestriction)ToCircles() []LatlongCircle { return nil }
+func (cb ClassBRestriction)ToLines() []LatlongLine {
+	ret := []LatlongLine{}
+	for _,v := range cb.volumes() { ret = append(ret, v.Area.ToLines()...) }
+	return ret
+}
+
+func (cb ClassBRestriction)BoundingBox() LatlongBox {
+	vols := cb.volumes()
+	if len(vols) == 0 { return LatlongBox{} }
+	box := vols[0].Area.BoundingBox()
+	for _,v := range vols[1:] {
+		b := v.Area.BoundingBox()
+		box.Enclose(b.SW)
+		box.Enclose(b.NE)
+	}
+	return box
+}
+func (cb ClassBRestriction)CanContain() bool { return true }
+func (cb ClassBRestriction)Contains(pos Latlong) bool {
+	for _,v := range cb.volumes() {
+		if v.Area.Contains(pos) { return true }
+	}
+	return false
+}
+
+func (cb ClassBRestriction)OverlapsLine(ln LatlongLine) OverlapOutcome {
+	crosses := func(ln LatlongLine) bool {
+		for _,v := range cb.volumes() {
+			if !v.Area.OverlapsLine(ln).IsDisjoint() { return true }
+		}
+		return false
+	}
+	return lineOverlap(ln, cb.Contains, crosses)
+}
+
+func (cb ClassBRestriction)OverlapsAltitude(a int64) OverlapOutcome {
+	floor,ceil := math.Inf(1), math.Inf(-1)
+	for _,v := range cb.volumes() {
+		floor = math.Min(floor, v.FloorAt(0))
+		ceil  = math.Max(ceil, v.CeilAt(0))
+	}
+
 	// r2 is the altitude; so if too low, it comes 'before' the restriction
-	if pr.AltitudeMin > 0 &&  a < pr.AltitudeMin { return DisjointR2ComesBefore }
-	if pr.AltitudeMax > 0 &&  a > pr.AltitudeMax { return DisjointR2ComesAfter }
+	if float64(a) < floor { return DisjointR2ComesBefore }
+	if float64(a) > ceil  { return DisjointR2ComesAfter }
 	return OverlapR2IsContained
 }
+
+// OverlapsAltitudeAt uses the floor and ceiling of the shelf at the position; if the position is
+// not within any of the shelves, the outcome is Disjoint. It implements AltitudeAtIntersector.
+func (cb ClassBRestriction)OverlapsAltitudeAt(pos Latlong, a int64) OverlapOutcome {
+	for _,v := range cb.volumes() {
+		if !v.Area.Contains(pos) { continue }
+		if float64(a) < v.FloorAt(0) { return DisjointR2ComesBefore }
+		if float64(a) > v.CeilAt(0)  { return DisjointR2ComesAfter }
+		return OverlapR2IsContained
+	}
+	return Disjoint
+}
@@ -0,0 +1,303 @@
+package geo
+// go test -v github.com/skypies/geo
+
+import(
+	"bytes"
+	"encoding/gob"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestCircleRestriction(t *testing.T) {
+	var r Restrictor = CircleRestriction{
+		NamedLatlong: NamedLatlong{"CENTER", Latlong{37,-122}},
+		RadiusKM: 10,
+		AltitudeMin: 2000,
+		AltitudeMax: 6000,
+		Debugger: new(DebugLog),
+	}
+	center := Latlong{37,-122}
+
+	if !r.Contains(center) || r.Contains(center.MoveKM(90,11)) {
+		t.Errorf("Contains: bad answers for %s\n", r)
+	}
+	if actual := r.OverlapsLine(center.LineTo(center.MoveKM(0,20))); actual != OverlapR2StraddlesEnd {
+		t.Errorf("OverlapsLine: expected OverlapR2StraddlesEnd, saw %v\n", actual)
+	}
+	if !r.BoundingBox().Contains(center.MoveKM(45,9.9)) {
+		t.Errorf("BoundingBox: %s too small\n", r.BoundingBox())
+	}
+
+	altTests := []struct{
+		Alt      int64
+		Expected OverlapOutcome
+	}{
+		{1000, DisjointR2ComesBefore},
+		{4000, OverlapR2IsContained},
+		{8000, DisjointR2ComesAfter},
+	}
+	for i,test := range altTests {
+		if actual := r.OverlapsAltitude(test.Alt); actual != test.Expected {
+			t.Errorf("OverlapsAltitude[%d]: expected %v, saw %v\n", i, test.Expected, actual)
+		}
+	}
+}
+
+func TestCircleRestrictionCGI(t *testing.T) {
+	names := FixMap{"CENTER": Latlong{37,-122}}
+	tests := []CircleRestriction{
+		{NamedLatlong: NamedLatlong{"CENTER", Latlong{37,-122}}, RadiusKM: 10},
+		{NamedLatlong: NamedLatlong{"", Latlong{37.1,-122.2}}, RadiusKM: 2.5, AltitudeMax: 8000},
+		{NamedLatlong: NamedLatlong{"", Latlong{37.1,-122.2}}, RadiusKM: 2.5, AltitudeMin: 2000,
+			AltitudeMax: 8000, IsExcluding: true},
+	}
+
+	for i,test := range tests {
+		r,_ := http.NewRequest("GET", "/?"+test.ToCGIArgs("cr"), nil)
+		actual := FormValueCircleRestriction(r, names, "cr")
+		if actual.Name != test.Name || !actual.Latlong.Equal(test.Latlong) ||
+			math.Abs(actual.RadiusKM - test.RadiusKM) > 0.01 ||
+			actual.AltitudeMin != test.AltitudeMin || actual.AltitudeMax != test.AltitudeMax ||
+			actual.IsExcluding != test.IsExcluding {
+			t.Errorf("CGI[%d]: round trip failed:\n in: %s\nout: %s\n", i, test, actual)
+		}
+	}
+}
+
+// An "M" shape, with a concave void at the top
+func mPolygon() *Polygon {
+	poly := NewPolygon()
+	poly.AddPoint(Latlong{ 37.0, -122.0})
+	poly.AddPoint(Latlong{ 37.0, -121.9})
+	poly.AddPoint(Latlong{ 37.05,-121.95})
+	poly.AddPoint(Latlong{ 37.1, -121.9})
+	poly.AddPoint(Latlong{ 37.1, -122.0})
+	return poly
+}
+
+func TestPolygonRestriction(t *testing.T) {
+	var r Restrictor = PolygonRestriction{
+		Polygon: mPolygon(),
+		Name: "M",
+		AltitudeMin: 3000,
+		Debugger: new(DebugLog),
+	}
+
+	containsTests := []struct{
+		Pos      Latlong
+		Expected bool
+	}{
+		{Latlong{37.02, -121.98}, true},
+		{Latlong{37.05, -121.92}, false}, // in the concave void
+		{Latlong{37.05, -121.97}, true},
+		{Latlong{37.2,  -121.95}, false},
+	}
+	for i,test := range containsTests {
+		if actual := r.Contains(test.Pos); actual != test.Expected {
+			t.Errorf("Contains[%d]: expected %v, saw %v\n", i, test.Expected, actual)
+		}
+	}
+
+	lineTests := []struct{
+		Expected OverlapOutcome
+		A,B      Latlong
+	}{
+		{Disjoint,                Latlong{37.2, -122.0},  Latlong{37.2, -121.9}},
+		{Disjoint,                Latlong{37.05,-121.92}, Latlong{37.05,-121.91}}, // in the void
+		{OverlapR2IsContained,    Latlong{37.02,-121.98}, Latlong{37.03,-121.97}},
+		{OverlapR2StraddlesEnd,   Latlong{37.02,-121.98}, Latlong{37.02,-121.8}},
+		{OverlapR2StraddlesStart, Latlong{37.02,-121.8},  Latlong{37.02,-121.98}},
+		{OverlapR2Contains,       Latlong{37.02,-122.1},  Latlong{37.02,-121.8}},
+	}
+	for i,test := range lineTests {
+		if actual := r.OverlapsLine(test.A.LineTo(test.B)); actual != test.Expected {
+			t.Errorf("OverlapsLine[%d]: expected %v, saw %v\n", i, test.Expected, actual)
+		}
+	}
+
+	altTests := []struct{
+		Alt      int64
+		Expected OverlapOutcome
+	}{
+		{2000,  DisjointR2ComesBefore},
+		{3000,  OverlapR2IsContained},
+		{40000, OverlapR2IsContained}, // no ceiling
+	}
+	for i,test := range altTests {
+		if actual := r.OverlapsAltitude(test.Alt); actual != test.Expected {
+			t.Errorf("OverlapsAltitude[%d]: expected %v, saw %v\n", i, test.Expected, actual)
+		}
+	}
+
+	box := r.BoundingBox()
+	if !box.SW.Equal(Latlong{37.0,-122.0}) || !box.NE.Equal(Latlong{37.1,-121.9}) {
+		t.Errorf("BoundingBox: wrong box %s\n", box)
+	}
+
+	if str := r.String(); str != "Polygon M 5-gon ~5.69KM @ (37.0500,-121.9500) [3000,-]ft" {
+		t.Errorf("String: saw %q\n", str)
+	}
+	if str := (PolygonRestriction{}).String(); str != "Polygon [nil]" {
+		t.Errorf("String: saw %q for empty restriction\n", str)
+	}
+}
+
+func TestPolygonRestrictionCGI(t *testing.T) {
+	in := PolygonRestriction{Polygon: mPolygon(), Name: "M", AltitudeMin: 3000, AltitudeMax: 8000,
+		IsExcluding: true}
+
+	r,_ := http.NewRequest("GET", "/?"+in.ToCGIArgs("pr"), nil)
+	out := FormValuePolygonRestriction(r, "pr")
+
+	if out.String() != in.String() {
+		t.Errorf("CGI round trip failed:\n in: %s\nout: %s\n", in, out)
+	}
+	inPts,outPts := in.GetPoints(), out.GetPoints()
+	if len(inPts) != len(outPts) {
+		t.Fatalf("CGI round trip: expected %d points, saw %d\n", len(inPts), len(outPts))
+	}
+	for i := range inPts {
+		if !inPts[i].Equal(outPts[i]) {
+			t.Errorf("CGI round trip: point %d: expected %s, saw %s\n", i, inPts[i], outPts[i])
+		}
+	}
+}
+
+func TestClassBRestriction(t *testing.T) {
+	m := testWrappingClassBMap // North: 0-5NM SFC, 5-10NM 20, 10-15NM 40; east: 0-5 SFC, 5-10 20
+	north := func(nm float64) Latlong { return m.Center.MoveKM(0, NM2KM(nm)) }
+	east  := func(nm float64) Latlong { return m.Center.MoveKM(90, NM2KM(nm)) }
+
+	shelfCB,err := NewClassBRestriction(m, "100/20", false)
+	if err != nil { t.Fatal(err) }
+	whole,_ := NewClassBRestriction(m, "", false)
+	var shelf Restrictor = shelfCB
+
+	containsTests := []struct{
+		Pos      Latlong
+		Expected bool
+	}{
+		{north(3),  false},
+		{north(7),  true},
+		{east(7),   true}, // same shelf, in another sector
+		{north(12), false},
+	}
+	for i,test := range containsTests {
+		if actual := shelf.Contains(test.Pos); actual != test.Expected {
+			t.Errorf("Contains[%d]: expected %v, saw %v", i, test.Expected, actual)
+		}
+	}
+
+	lineTests := []struct{
+		Expected OverlapOutcome
+		A,B      Latlong
+	}{
+		{OverlapR2StraddlesStart, north(3),  north(7)},
+		{OverlapR2IsContained,    north(6),  north(8)},
+		{OverlapR2Contains,       north(3),  north(12)},
+		{Disjoint,                north(12), north(14)},
+	}
+	for i,test := range lineTests {
+		if actual := shelf.OverlapsLine(test.A.LineTo(test.B)); actual != test.Expected {
+			t.Errorf("OverlapsLine[%d]: expected %v, saw %v", i, test.Expected, actual)
+		}
+	}
+
+	if actual := shelf.OverlapsAltitude(1500); actual != DisjointR2ComesBefore {
+		t.Errorf("OverlapsAltitude: expected DisjointR2ComesBefore, saw %v", actual)
+	}
+	if actual := whole.OverlapsAltitude(1500); actual != OverlapR2IsContained {
+		t.Errorf("OverlapsAltitude: expected OverlapR2IsContained for the whole map, saw %v", actual)
+	}
+
+	atTests := []struct{
+		Pos      Latlong
+		Alt      int64
+		Expected OverlapOutcome
+	}{
+		{north(3),  1500, OverlapR2IsContained},
+		{north(7),  1500, DisjointR2ComesBefore},
+		{north(12), 3500, DisjointR2ComesBefore},
+		{north(12), 4500, OverlapR2IsContained},
+		{east(12),  4500, Disjoint},
+	}
+	for i,test := range atTests {
+		if actual := whole.OverlapsAltitudeAt(test.Pos, test.Alt); actual != test.Expected {
+			t.Errorf("OverlapsAltitudeAt[%d]: expected %v, saw %v", i, test.Expected, actual)
+		}
+	}
+
+	if !shelf.BoundingBox().Contains(east(9.9)) || shelf.BoundingBox().Contains(north(10.5)) {
+		t.Errorf("BoundingBox: wrong box %s", shelf.BoundingBox())
+	}
+	if (ClassBRestriction{Map: m, Shelf: "100/99"}).IsNil() == false {
+		t.Errorf("IsNil: a non-existent shelf should be nil")
+	}
+	if _,err := NewClassBRestriction(m, "100-20", false); err == nil {
+		t.Errorf("NewClassBRestriction: expected an error for a bad shelf")
+	}
+
+	// A track at 1800ft, heading north under the 20 shelf and then the 40 shelf, before climbing
+	// into the 40 shelf; evaluated as restriction code would, via OverlapsAltitudeAt.
+	track := []struct{
+		Pos      Latlong
+		Alt      int64
+		Expected OverlapOutcome
+	}{
+		{north(2),  1800, OverlapR2IsContained},  // Surface area
+		{north(6),  1800, DisjointR2ComesBefore}, // Under the 20 shelf
+		{north(9),  1800, DisjointR2ComesBefore},
+		{north(11), 1800, DisjointR2ComesBefore}, // Under the 40 shelf
+		{north(13), 4200, OverlapR2IsContained},
+		{north(16), 4200, Disjoint},              // Beyond the map
+	}
+	for i,test := range track {
+		if actual := OverlapsAltitudeAt(whole, test.Pos, test.Alt); actual != test.Expected {
+			t.Errorf("track[%d]: expected %v, saw %v", i, test.Expected, actual)
+		}
+	}
+
+	// Restrictions without a varying floor fall back to OverlapsAltitude
+	cr := CircleRestriction{NamedLatlong: NamedLatlong{"X", m.Center}, RadiusKM: 10, AltitudeMin: 2000}
+	if actual := OverlapsAltitudeAt(cr, north(2), 1800); actual != DisjointR2ComesBefore {
+		t.Errorf("OverlapsAltitudeAt(circle): expected DisjointR2ComesBefore, saw %v", actual)
+	}
+}
+
+func TestRestrictionGob(t *testing.T) {
+	in := []Restrictor{
+		PolygonRestriction{Polygon: mPolygon(), Name: "M", AltitudeMax: 5000},
+		CircleRestriction{NamedLatlong: NamedLatlong{"X", Latlong{37,-122}}, RadiusKM: 10},
+		SectorRestriction{Sector: Sector{Latlong{37,-122}, 5, 10, 330, 30}, AltitudeMax: 4000},
+		AnnulusRestriction{Annulus: Annulus{Latlong{37,-122}, 5, 10}, IsExcluding: true},
+		ClassBRestriction{Map: testWrappingClassBMap, Shelf: "100/20"},
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatalf("gob encode: %v", err)
+	}
+	out := []Restrictor{}
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatalf("gob decode: %v", err)
+	}
+
+	if len(out) != len(in) {
+		t.Fatalf("gob: expected %d restrictors, saw %d", len(in), len(out))
+	}
+	for i := range in {
+		if in[i].String() != out[i].String() {
+			t.Errorf("gob[%d]: expected %s, saw %s", i, in[i], out[i])
+		}
+		if pos := (Latlong{37.02,-121.98}); in[i].Contains(pos) != out[i].Contains(pos) {
+			t.Errorf("gob[%d]: Contains() differs after decoding", i)
+		}
+	}
+	if pt,_ := out[0].(PolygonRestriction).Polygon.transients.Load().(*polygonTransients); pt == nil {
+		t.Errorf("gob: polygon transients were not cached after first use")
+	}
+	if cb := out[4].(ClassBRestriction); len(cb.vols) == 0 {
+		t.Errorf("gob: ClassB volumes were not rebuilt on decode")
+	}
+}
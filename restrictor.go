@@ -24,8 +24,56 @@ type Intersector interface {
 	OverlapsAltitude(int64) OverlapOutcome // DisjointR2ComesAfter == val was above the GR
 }	
 
+// AltitudeAtIntersector is implemented by intersectors whose floor or ceiling varies with
+// position, such as ClassB maps.
+type AltitudeAtIntersector interface {
+	OverlapsAltitudeAt(Latlong, int64) OverlapOutcome
+}
+
+// OverlapsAltitudeAt is the altitude check to use when evaluating a track point against an
+// intersector; it uses the vertical extent at the point if the intersector knows it, and falls
+// back to OverlapsAltitude if not.
+func OverlapsAltitudeAt(i Intersector, pos Latlong, alt int64) OverlapOutcome {
+	if ai,ok := i.(AltitudeAtIntersector); ok { return ai.OverlapsAltitudeAt(pos, alt) }
+	return i.OverlapsAltitude(alt)
+}
+
+// lineOverlap works out a line's relation to an area, from the area's Contains, and a test for
+// the line crossing its boundary (only used if both ends are outside). r2 is the line.
+func lineOverlap(l LatlongLine, contains func(Latlong) bool, crosses func(LatlongLine) bool) OverlapOutcome {
+	sInside,eInside := contains(l.From), contains(l.To)
+
+	// If any of it is inside, figure out the line's relation to the area
+	if sInside && eInside { return OverlapR2IsContained }
+	if sInside            { return OverlapR2StraddlesEnd }
+	if eInside            { return OverlapR2StraddlesStart }
+
+	// Points are outside. So, if we cross the boundary, then the line contains the area.
+	if crosses(l) { return OverlapR2Contains }
+	return Disjoint
+}
+
+// altitudeOverlap compares an altitude with a vertical extent; zero limits are unbounded. r2 is
+// the altitude; so if too low, it comes 'before' the extent.
+func altitudeOverlap(a, min, max int64) OverlapOutcome {
+	if min > 0 && a < min { return DisjointR2ComesBefore }
+	if max > 0 && a > max { return DisjointR2ComesAfter }
+	return OverlapR2IsContained
+}
+
 type Restrictor interface {
 	Intersector
 	IsExclusion() bool // I.e. the restriction means "do not intersect this thing"
 	IsNil() bool
 }
+
+// Area is a laterally bounded 2D shape, such as a polygon, circle or sector.
+type Area interface {
+	MapRenderer
+
+	BoundingBox() LatlongBox
+	Contains(Latlong) bool
+	OverlapsLine(LatlongLine) OverlapOutcome
+	NearestBoundaryPoint(Latlong) Latlong
+	SignedDistanceKM(Latlong) float64 // -ve if inside
+}
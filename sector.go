@@ -0,0 +1,230 @@
+package geo
+
+import(
+	"fmt"
+	"math"
+)
+
+// How finely to chop up arcs when rendering them (or bounding them) as lines, in degrees
+const kArcRenderStepDegrees = 10.0
+
+// Sector is a slice of a ring: the area between two radii from the center, that also lies
+// between two bearings (measured from the center, clockwise from StartBearing to EndBearing). An
+// inner radius of zero gives a pie wedge; bearings of 0 and 360 give a full ring.
+type Sector struct {
+	Center                       Latlong
+	InnerRadiusKM, OuterRadiusKM float64
+	StartBearing, EndBearing     float64
+}
+
+// Annulus is a ring; the area between two radii from the center.
+type Annulus struct {
+	Center                       Latlong
+	InnerRadiusKM, OuterRadiusKM float64
+}
+
+func (s Sector)String() string {
+	return fmt.Sprintf("Sector %s %.1f-%.1fKM %03.0f-%03.0fdeg", s.Center, s.InnerRadiusKM,
+		s.OuterRadiusKM, s.StartBearing, s.EndBearing)
+}
+func (a Annulus)String() string {
+	return fmt.Sprintf("Annulus %s %.1f-%.1fKM", a.Center, a.InnerRadiusKM, a.OuterRadiusKM)
+}
+
+// An annulus is just a sector that goes all the way round
+func (a Annulus)Sector() Sector {
+	return Sector{a.Center, a.InnerRadiusKM, a.OuterRadiusKM, 0, 360}
+}
+
+// {{{ s.IsFullCircle, s.SpanDegrees, s.InnerCircle, s.OuterCircle
+
+func (s Sector)IsFullCircle() bool {
+	return s.StartBearing == s.EndBearing || math.Abs(s.EndBearing - s.StartBearing) >= 360.0
+}
+
+// SpanDegrees is how many degrees the sector covers, going clockwise from StartBearing.
+func (s Sector)SpanDegrees() float64 {
+	if s.IsFullCircle() { return 360.0 }
+	return math.Mod(s.EndBearing - s.StartBearing + 720.0, 360.0)
+}
+
+func (s Sector)InnerCircle() LatlongCircle { return s.Center.Circle(s.InnerRadiusKM) }
+func (s Sector)OuterCircle() LatlongCircle { return s.Center.Circle(s.OuterRadiusKM) }
+
+func (s Sector)inBearingRange(pos Latlong) bool {
+	if s.IsFullCircle() { return true }
+	return BearingInRange(s.Center.BearingTowards(pos), s.StartBearing, s.EndBearing)
+}
+
+// }}}
+// {{{ s.arc, s.radials
+
+// arc returns points along the arc of the given radius, from StartBearing to EndBearing.
+func (s Sector)arc(radiusKM float64) []Latlong {
+	span := s.SpanDegrees()
+	n := int(math.Ceil(span / kArcRenderStepDegrees))
+	ret := []Latlong{}
+	for i:=0; i<=n; i++ {
+		ret = append(ret, s.Center.MoveKM(s.StartBearing + span*float64(i)/float64(n), radiusKM))
+	}
+	return ret
+}
+
+// radials returns the two straight edges of the sector, both running outwards from the center.
+// A full circle has none.
+func (s Sector)radials() []LatlongLine {
+	if s.IsFullCircle() { return []LatlongLine{} }
+	ret := []LatlongLine{}
+	for _,bearing := range []float64{s.StartBearing, s.EndBearing} {
+		in := s.Center.MoveKM(bearing, s.InnerRadiusKM)
+		out := s.Center.MoveKM(bearing, s.OuterRadiusKM)
+		ret = append(ret, in.LineTo(out))
+	}
+	return ret
+}
+
+// }}}
+
+// {{{ s.Contains
+
+func (s Sector)Contains(pos Latlong) bool {
+	dist := s.Center.DistKM(pos)
+	if dist >= s.OuterRadiusKM { return false }
+	if dist < s.InnerRadiusKM { return false }
+	return s.inBearingRange(pos)
+}
+
+// }}}
+// {{{ s.IntersectsLine
+
+// IntersectsLine returns the points where the bounded line crosses the edge of the sector. They
+// are not in any particular order.
+func (s Sector)IntersectsLine(l LatlongLine) ([]Latlong, bool) {
+	ret := []Latlong{}
+
+	circles := []LatlongCircle{s.OuterCircle()}
+	if s.InnerRadiusKM > 0 { circles = append(circles, s.InnerCircle()) }
+	for _,c := range circles {
+		pts,_ := c.IntersectionPoints(l)
+		for _,pt := range pts {
+			if s.inBearingRange(pt) { ret = append(ret, pt) }
+		}
+	}
+
+	for _,radial := range s.radials() {
+		if pt,intersects := radial.Intersects(l); intersects {
+			ret = append(ret, pt)
+		}
+	}
+
+	return ret, (len(ret)>0)
+}
+
+// }}}
+// {{{ s.OverlapsLine
+
+// A line that crosses an edge, with both ends outside, is deemed to contain the sector.
+// (Strictly, a line could cut through a concave sector, entering and leaving more than once.)
+func (s Sector)OverlapsLine(l LatlongLine) OverlapOutcome {
+	crosses := func(l LatlongLine) bool { _,intersects := s.IntersectsLine(l); return intersects }
+	o := lineOverlap(l, s.Contains, crosses)
+
+	// Both ends can be inside while the line leaves in between; e.g. a chord across the hole in
+	// an annulus, or across the gap of a sector wider than 180 degrees.
+	if o == OverlapR2IsContained && crosses(l) { return OverlapStraddles }
+	return o
+}
+
+// }}}
+// {{{ s.NearestBoundaryPoint, s.SignedDistanceKM
+
+func (s Sector)NearestBoundaryPoint(pos Latlong) Latlong {
+	candidates := []Latlong{}
+
+	// The nearest point on an arc is either straight out along the bearing to pos, or one
+	// of the ends of the arc.
+	radii := []float64{s.OuterRadiusKM}
+	if s.InnerRadiusKM > 0 { radii = append(radii, s.InnerRadiusKM) }
+	for _,radius := range radii {
+		if s.inBearingRange(pos) {
+			candidates = append(candidates, s.Center.Circle(radius).NearestBoundaryPoint(pos))
+		}
+		if !s.IsFullCircle() {
+			candidates = append(candidates, s.Center.MoveKM(s.StartBearing, radius),
+				s.Center.MoveKM(s.EndBearing, radius))
+		}
+	}
+
+	for _,radial := range s.radials() {
+		candidates = append(candidates, radial.ClosestToSegment(pos))
+	}
+
+	closest,closestDist := Latlong{}, math.MaxFloat64
+	for _,candidate := range candidates {
+		if dist := pos.DistKM(candidate); dist < closestDist {
+			closest,closestDist = candidate, dist
+		}
+	}
+	return closest
+}
+
+// SignedDistanceKM is the distance from pos to the nearest edge of the sector; negative if inside.
+func (s Sector)SignedDistanceKM(pos Latlong) float64 {
+	dist := pos.DistKM(s.NearestBoundaryPoint(pos))
+	if s.Contains(pos) { return -1 * dist }
+	return dist
+}
+
+// }}}
+// {{{ s.BoundingBox
+
+func (s Sector)BoundingBox() LatlongBox {
+	pts := s.arc(s.OuterRadiusKM)
+	pts = append(pts, s.arc(s.InnerRadiusKM)...)
+
+	// The extremes of the outer arc are at the cardinal points, if the arc reaches them
+	for _,bearing := range []float64{0, 90, 180, 270} {
+		if s.IsFullCircle() || BearingInRange(bearing, s.StartBearing, s.EndBearing) {
+			pts = append(pts, s.Center.MoveKM(bearing, s.OuterRadiusKM))
+		}
+	}
+
+	box := pts[0].BoxTo(pts[0])
+	for _,pt := range pts[1:] {
+		box.Enclose(pt)
+	}
+	return box
+}
+
+// }}}
+
+// Implement MapRenderer interface
+func (s Sector)ToCircles() []LatlongCircle { return []LatlongCircle{} }
+func (s Sector)ToLines() []LatlongLine {
+	ret := []LatlongLine{}
+	addPath := func(pts []Latlong) {
+		for i:=1; i<len(pts); i++ {
+			ret = append(ret, pts[i-1].LineTo(pts[i]))
+		}
+	}
+
+	addPath(s.arc(s.OuterRadiusKM))
+	if s.InnerRadiusKM > 0 { addPath(s.arc(s.InnerRadiusKM)) }
+	ret = append(ret, s.radials()...)
+
+	return ret
+}
+
+// Annulus just delegates to the equivalent sector
+func (a Annulus)Contains(pos Latlong) bool { return a.Sector().Contains(pos) }
+func (a Annulus)IntersectsLine(l LatlongLine) ([]Latlong, bool) {
+	return a.Sector().IntersectsLine(l)
+}
+func (a Annulus)OverlapsLine(l LatlongLine) OverlapOutcome { return a.Sector().OverlapsLine(l) }
+func (a Annulus)NearestBoundaryPoint(pos Latlong) Latlong {
+	return a.Sector().NearestBoundaryPoint(pos)
+}
+func (a Annulus)SignedDistanceKM(pos Latlong) float64 { return a.Sector().SignedDistanceKM(pos) }
+func (a Annulus)BoundingBox() LatlongBox { return a.Sector().BoundingBox() }
+func (a Annulus)ToCircles() []LatlongCircle { return []LatlongCircle{} }
+func (a Annulus)ToLines() []LatlongLine { return a.Sector().ToLines() }
@@ -0,0 +1,125 @@
+package geo
+// go test -v github.com/skypies/geo
+
+import(
+	"math"
+	"testing"
+)
+
+func TestBearingInRange(t *testing.T) {
+	tests := []struct{
+		Bearing, Start, End float64
+		Expected            bool
+	}{
+		{ 45,   0,  90, true},
+		{ 95,   0,  90, false},
+		{  0,   0,  90, true},
+		{ 90,   0,  90, true},
+		{350, 330,  30, true},  // wraps through north
+		{ 10, 330,  30, true},
+		{ 40, 330,  30, false},
+		{180,   0, 360, true},  // full circle
+		{180,  90,  90, true},
+		{-10, 330,  30, true},
+	}
+
+	for i,test := range tests {
+		if actual := BearingInRange(test.Bearing, test.Start, test.End); actual != test.Expected {
+			t.Errorf("[t%d] BearingInRange(%.0f,%.0f,%.0f): expected %v, got %v", i, test.Bearing,
+				test.Start, test.End, test.Expected, actual)
+		}
+	}
+}
+
+func TestSector(t *testing.T) {
+	center := Latlong{37,-122}
+	s := Sector{center, 10, 20, 330, 30} // A ring segment, wrapping through north
+
+	containsTests := []struct{
+		Pos      Latlong
+		Expected bool
+	}{
+		{center.MoveKM(  0, 15), true},
+		{center.MoveKM(340, 15), true},
+		{center.MoveKM( 20, 15), true},
+		{center.MoveKM(  0,  5), false}, // inside the inner radius
+		{center.MoveKM(  0, 25), false}, // beyond the outer radius
+		{center.MoveKM( 90, 15), false}, // wrong bearing
+	}
+	for i,test := range containsTests {
+		if actual := s.Contains(test.Pos); actual != test.Expected {
+			t.Errorf("Contains[%d]: expected %v, saw %v\n", i, test.Expected, actual)
+		}
+	}
+
+	lineTests := []struct{
+		Expected OverlapOutcome
+		A,B      Latlong
+	}{
+		{OverlapR2IsContained,    center.MoveKM(350,15), center.MoveKM(10,15)},
+		{OverlapR2StraddlesEnd,   center.MoveKM(  0,15), center.MoveKM(0,30)},
+		{OverlapR2StraddlesStart, center.MoveKM(  0,30), center.MoveKM(0,15)},
+		{OverlapR2Contains,       center.MoveKM(270,15), center.MoveKM(0,30).MoveKM(90,15)},
+		{Disjoint,                center.MoveKM(180,15), center.MoveKM(90,15)},
+		{Disjoint,                center.MoveKM(270,5),  center.MoveKM(90,5)}, // passes the hole
+		{OverlapStraddles,        center.MoveKM(335,10.5), center.MoveKM(25,10.5)}, // cuts across the hole
+	}
+	for i,test := range lineTests {
+		if actual := s.OverlapsLine(test.A.LineTo(test.B)); actual != test.Expected {
+			t.Errorf("OverlapsLine[%d]: expected %v, saw %v\n", i, test.Expected, actual)
+		}
+	}
+
+	box := s.BoundingBox()
+	for _,pt := range []Latlong{center.MoveKM(0,20), center.MoveKM(330,20), center.MoveKM(30,10)} {
+		if !box.Contains(pt) {
+			t.Errorf("BoundingBox: %s does not contain %s\n", box, pt)
+		}
+	}
+	if box.Contains(center) {
+		t.Errorf("BoundingBox: %s too big; contains center\n", box)
+	}
+
+	distTests := []struct{
+		Pos    Latlong
+		DistKM float64
+	}{
+		{center.MoveKM(  0, 15), -5},
+		{center.MoveKM(  0, 22),  2},
+		{center.MoveKM(  0,  7),  3},
+		{center,                 10}, // nearest is the start of the inner arc
+	}
+	for i,test := range distTests {
+		if actual := s.SignedDistanceKM(test.Pos); math.Abs(actual - test.DistKM) > 0.01 {
+			t.Errorf("SignedDistanceKM[%d]: expected %.2f, saw %.2f\n", i, test.DistKM, actual)
+		}
+	}
+
+	// Two arcs of 6 lines each, plus two radials
+	if n := len(s.ToLines()); n != 14 {
+		t.Errorf("ToLines: expected 14 lines, saw %d\n", n)
+	}
+}
+
+func TestAnnulus(t *testing.T) {
+	center := Latlong{37,-122}
+	a := Annulus{center, 10, 20}
+
+	for _,bearing := range []float64{0, 90, 180, 270} {
+		if !a.Contains(center.MoveKM(bearing, 15)) {
+			t.Errorf("Contains: bearing %.0f should be contained\n", bearing)
+		}
+	}
+	if a.Contains(center) {
+		t.Errorf("Contains: the center is in the hole\n")
+	}
+	if actual := a.OverlapsLine(center.MoveKM(270,30).LineTo(center.MoveKM(90,30))); actual != OverlapR2Contains {
+		t.Errorf("OverlapsLine: expected OverlapR2Contains, saw %v\n", actual)
+	}
+	if actual := a.SignedDistanceKM(center.MoveKM(45,12)); math.Abs(actual - -2) > 0.01 {
+		t.Errorf("SignedDistanceKM: expected -2, saw %.2f\n", actual)
+	}
+	if !a.BoundingBox().Contains(center.MoveKM(90,19.9)) {
+		t.Errorf("BoundingBox: too small\n")
+	}
+}
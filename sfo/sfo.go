@@ -1,8 +1,10 @@
 package sfo
 
 import(
+	"fmt"
 	"net/http"
 	"sort"
+	"strings"
 	"github.com/skypies/geo"
 )
 
@@ -202,8 +204,9 @@ var (
 	SFOClassBMap = geo.ClassBMap{
 		Name: "SFO",
 		Center: KLatlongSFO,
+		Declination: 13.68, // Sector bearings are magnetic radials out from SFO
 		Sectors: []geo.ClassBSector{
-			// Magnetic declination at SFO: 13.68
+			// Sectors must be in ascending order, and go all the way round; see Validate()
 			geo.ClassBSector{
 				StartBearing: 0,
 				EndBearing: 360,
@@ -237,18 +240,97 @@ var (
 	}
 )
 
+// NewFixRegistry builds a registry from KFixes and KAirports. Entries named X_ are marked as
+// personal, and names such as NARWL-SERFR3 are aliased to the base fix name when it is free.
+// Anything that can't be added is reported as a conflict.
+func NewFixRegistry() (*geo.FixRegistry, []geo.FixConflict) {
+	names := []string{}
+	for name,_ := range KFixes { names = append(names, name) }
+	sort.Strings(names)
+
+	fr := geo.NewFixRegistry()
+	conflicts := []geo.FixConflict{}
+	for _,name := range names {
+		fe := geo.FixEntry{NamedLatlong:geo.NamedLatlong{name, KFixes[name]}, Type:"waypoint", Source:"sfo.KFixes"}
+		if strings.HasPrefix(name, "X_") { fe.Type = "personal" }
+		conflicts = append(conflicts, fr.AddOrConflict(fe)...)
+	}
+	for _,name := range names {
+		if i := strings.Index(name, "-"); i > 0 {
+			if _,exists := fr.Lookup(name[:i]); exists { continue }
+			if err := fr.AddAlias(name[:i], name); err != nil {
+				fe,_ := fr.Entry(name)
+				conflicts = append(conflicts, geo.FixConflict{Name:name[:i], Existing:fe, Incoming:fe})
+			}
+		}
+	}
+
+	airports,c := geo.NewFixRegistryFromMap(KAirports, "airport", "sfo.KAirports")
+	conflicts = append(conflicts, c...)
+	conflicts = append(conflicts, fr.Merge(airports, 0.1)...)
+	return fr, conflicts
+}
+
+// kProcedureCycles says when the procedures named in KFixes (as in NARWL-SERFR3) took effect.
+// SERFR3 has no published effective date yet; add it here, citing the AIRAC publication, once
+// it does (and set the Until of the procedures it replaces).
+var kProcedureCycles = map[string]geo.AIRACCycle{}
+
+// NewFixHistory builds a history from KFixes and KAirports. A fix named for a procedure with a
+// known effective date (e.g. EDDYY-SERFR3) becomes the version of the base fix (EDDYY) from
+// then, and any older version of the base fix is in force only until then. Fixes for
+// procedures with no known date are undated, as in NewFixRegistry.
+func NewFixHistory() (*geo.FixHistory, error) { return newFixHistory(kProcedureCycles) }
+
+// newFixHistory uses the given cycles for the procedures; it does not modify them.
+func newFixHistory(cycles map[string]geo.AIRACCycle) (*geo.FixHistory, error) {
+	fr,conflicts := NewFixRegistry()
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%d conflicts in the fixes, including %s", len(conflicts), conflicts[0])
+	}
+
+	fh := geo.NewFixHistory()
+	for _,fe := range fr.Entries() {
+		if i := strings.Index(fe.Name, "-"); i > 0 {
+			if c,exists := cycles[fe.Name[i+1:]]; exists {
+				fe.Name,fe.Aliases,fe.Effective = fe.Name[:i], []string{fe.Name}, c.Effective()
+			}
+		} else {
+			fe.Aliases = nil
+			for proc,c := range cycles {
+				if _,exists := KFixes[fe.Name+"-"+proc]; exists { fe.Until = c.Effective() }
+			}
+		}
+		if err := fh.Add(fe); err != nil { return nil, err }
+	}
+	return fh, nil
+}
+
+// kFixTable is built once, at init, from KFixes and KAirports. It is read-only, so lookups from
+// concurrent handlers are safe; changes made to KFixes or KAirports after init are not seen.
+// The bundled data should have no conflicts; the tests check kFixConflicts is empty.
+var kFixTable, kFixConflicts = func() (geo.FixTable, []geo.FixConflict) {
+	fr,conflicts := NewFixRegistry()
+	return fr.Table(), conflicts
+}()
+
+// Fixes returns the read-only table of SFO fixes and airports. Use Fixes().Registry() to get a
+// copy that can be added to.
+func Fixes() geo.FixTable { return kFixTable }
+
+// ListWaypoints returns the names of the fixes (but not the airports), sorted.
 func ListWaypoints() []string {
 	ret := []string{}
-	for k,_ := range KFixes { ret = append(ret,k) }
+	for _,name := range kFixTable.Names() {
+		if fe,_ := kFixTable.Entry(name); fe.Type != "airport" { ret = append(ret, name) }
+	}
 	sort.Strings(ret)
 	return ret
 }
 
 // A version of this which looks up against SFO names
 func FormValueNamedLatlong(r *http.Request, stem string) geo.NamedLatlong {
-	vals := KFixes
-	for k,v := range KAirports { vals[k] = v }
-	return geo.FormValueNamedLatlong(r, vals, stem)
+	return geo.FormValueNamedLatlong(r, kFixTable, stem)
 }
 
 
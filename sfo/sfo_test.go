@@ -0,0 +1,104 @@
+package sfo
+
+import(
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+	"github.com/skypies/geo"
+)
+
+func TestFixes(t *testing.T) {
+	tests := []struct{
+		Name     string
+		Expected string
+	}{
+		{"KSFO",  "KSFO"},
+		{"ksjc",  "KSJC"},
+		{"EPICK", "EPICK"},
+		{"narwl", "NARWL-SERFR3"}, // The base name is free, so is an alias
+		{"EDDYY", "EDDYY"},        // ... but this one isn't
+		{"NOSUCH", ""},
+	}
+	for i,test := range tests {
+		nl,exists := Fixes().Lookup(test.Name)
+		if exists != (test.Expected != "") || nl.Name != test.Expected {
+			t.Errorf("[%d] %s: expected '%s', saw '%s'", i, test.Name, test.Expected, nl.Name)
+		}
+	}
+
+	if fe,_ := Fixes().Entry("X_WSD"); fe.Type != "personal" {
+		t.Errorf("X_WSD: expected a personal entry, saw %s", fe)
+	}
+	if _,conflicts := NewFixRegistry(); len(conflicts) != 0 {
+		t.Errorf("unexpected conflicts: %v", conflicts)
+	}
+	if len(kFixConflicts) != 0 {
+		t.Fatalf("the fix table built at init has conflicts: %v", kFixConflicts)
+	}
+	if n := len(ListWaypoints()); n != len(KFixes) {
+		t.Errorf("ListWaypoints: expected %d, saw %d", len(KFixes), n)
+	}
+}
+
+// Run with -race; handlers used to write KAirports into KFixes on every request.
+func TestFormValueNamedLatlongConcurrent(t *testing.T) {
+	names := []string{"KSFO", "SERFR", "kOaK", "X_PVY", "NOSUCH"}
+
+	var wg sync.WaitGroup
+	for i:=0; i<50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := names[i % len(names)]
+			r,_ := http.NewRequest("GET", "/?"+url.Values{"pos_name":{name}}.Encode(), nil)
+			nl := FormValueNamedLatlong(r, "pos")
+			if (name == "NOSUCH") != (nl.Name == "[UNKNOWN]") {
+				t.Errorf("%s: saw %s", name, nl)
+			}
+			ListWaypoints()
+
+			// Callers can modify their own copies without harm
+			Fixes().Map()["KSFO"] = KLatlongSJC
+			Fixes().Registry().AddAlias("SFO", "KSFO")
+		}(i)
+	}
+	wg.Wait()
+
+	if _,exists := KFixes["KSFO"]; exists {
+		t.Errorf("KAirports leaked into KFixes")
+	}
+	if nl,_ := Fixes().Lookup("KSFO"); nl.Latlong != KLatlongSFO {
+		t.Errorf("the table was modified: %s", nl)
+	}
+	if _,exists := Fixes().Lookup("SFO"); exists {
+		t.Errorf("an alias leaked into the table")
+	}
+}
+
+func TestFixHistory(t *testing.T) {
+	// SERFR3 has no known date, so its fixes are undated, and don't replace the old ones
+	fh,err := NewFixHistory()
+	if err != nil { t.Fatal(err) }
+	now := time.Now()
+	if nl,_ := fh.LookupAt("EDDYY", now); !nl.Equal(KFixes["EDDYY"]) { t.Errorf("undated EDDYY: saw %s", nl) }
+	if nl,_ := fh.LookupAt("NARWL", now); nl.Name != "NARWL-SERFR3" { t.Errorf("undated NARWL: saw %s", nl) }
+	if !Serfr1.Until.IsZero() { t.Errorf("%s: has an end date before SERFR3 does", Serfr1.Name) }
+
+	// Once it has a date, the SERFR3 fixes take over from then
+	cycle := geo.AIRACCycle(1611) // Not a real date for SERFR3
+	before,after := cycle.Prev().Effective(), cycle.Effective()
+
+	fh,err = newFixHistory(map[string]geo.AIRACCycle{"SERFR3": cycle})
+	if err != nil { t.Fatal(err) }
+	if nl,_ := fh.LookupAt("EDDYY", before); !nl.Equal(KFixes["EDDYY"]) {
+		t.Errorf("EDDYY before SERFR3: saw %s", nl)
+	}
+	if nl,_ := fh.LookupAt("EDDYY", after); !nl.Equal(KFixes["EDDYY-SERFR3"]) {
+		t.Errorf("EDDYY after SERFR3: saw %s", nl)
+	}
+	if _,exists := fh.LookupAt("NARWL", before); exists { t.Errorf("NARWL before SERFR3") }
+	if nl,_ := fh.LookupAt("narwl-serfr3", after); nl.Name != "NARWL" { t.Errorf("NARWL-SERFR3: saw %s", nl) }
+	if _,exists := fh.LookupAt("EPICK", before); !exists { t.Errorf("no EPICK before SERFR3") }
+}
@@ -27,6 +27,14 @@ func (w Window)IntersectsAltitude(alt int64) bool {
 	return true
 }
 
+// A window has no inside, so the signed distance is never negative.
+func (w Window)NearestBoundaryPoint(pos Latlong) Latlong {
+	return w.LatlongLine.ClosestToSegment(pos)
+}
+func (w Window)SignedDistanceKM(pos Latlong) float64 {
+	return pos.DistKM(w.NearestBoundaryPoint(pos))
+}
+
 func (w Window)IntersectsLineDeb(l LatlongLine) (bool,string) { return w.IntersectsLine(l),"" }
 
 // Implement MapRenderer interface